@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 	"time"
 )
 
@@ -11,11 +12,16 @@ type Config struct {
 	Redis    RedisConfig
 	JWT      JWTConfig
 	Queue    QueueConfig
+	Storage  StorageConfig
 }
 
+// ServerConfig sets where the server listens. TrustedProxies are the reverse
+// proxies whose X-Forwarded-For is believed when working out a client's IP,
+// which login lockouts are keyed on; nil trusts none and uses the peer address.
 type ServerConfig struct {
-	Port string
-	Env  string
+	Port           string
+	Env            string
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -33,9 +39,12 @@ type RedisConfig struct {
 	DB       int
 }
 
+// JWTConfig sets token lifetimes. Expiration is how long an access token is
+// valid; RefreshExpiration how long a session can go without refreshing.
 type JWTConfig struct {
-	Secret     string
-	Expiration time.Duration
+	Secret            string
+	Expiration        time.Duration
+	RefreshExpiration time.Duration
 }
 
 type QueueConfig struct {
@@ -44,13 +53,22 @@ type QueueConfig struct {
 	AverageInterviewTime  int
 	BufferTime            int
 	GroupInterviewMaxSize int
+	CallGracePeriod       int
+}
+
+// StorageConfig locates uploaded files. Resumes are kept under ResumeDir on
+// local disk unless another blob store is wired in.
+type StorageConfig struct {
+	ResumeDir     string
+	MaxResumeSize int64
 }
 
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			Env:            getEnv("ENV", "development"),
+			TrustedProxies: getEnvList("TRUSTED_PROXIES"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -66,8 +84,9 @@ func Load() *Config {
 			DB:       0,
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "interview-system-secret-key-2025"),
-			Expiration: 24 * time.Hour,
+			Secret:            getEnv("JWT_SECRET", "interview-system-secret-key-2025"),
+			Expiration:        15 * time.Minute,
+			RefreshExpiration: 7 * 24 * time.Hour,
 		},
 		Queue: QueueConfig{
 			ActiveQueueLimit:      6,
@@ -75,6 +94,11 @@ func Load() *Config {
 			AverageInterviewTime:  8,
 			BufferTime:            5,
 			GroupInterviewMaxSize: 4,
+			CallGracePeriod:       3,
+		},
+		Storage: StorageConfig{
+			ResumeDir:     getEnv("RESUME_DIR", "uploads/resumes"),
+			MaxResumeSize: 5 << 20,
 		},
 	}
 }
@@ -84,4 +108,15 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getEnvList splits a comma separated variable, nil when it is unset or empty.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
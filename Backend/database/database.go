@@ -5,6 +5,7 @@ import (
 	"interview-system/config"
 	"interview-system/models"
 	"log"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/mysql"
@@ -27,19 +28,38 @@ func Initialize(cfg config.DatabaseConfig) (*gorm.DB, error) {
 		&models.Company{},
 		&models.User{},
 		&models.LoginRecord{},
+		&models.LoginLockout{},
+		&models.RefreshToken{},
+		&models.Invitation{},
 		&models.Position{},
 		&models.PositionInterviewer{},
+		&models.PositionRound{},
 		&models.CandidatePosition{},
 		&models.Interview{},
 		&models.GroupInterview{},
+		&models.GroupInterviewInvitation{},
 		&models.QueueEntry{},
 		&models.QueueOptimization{},
-		&models.ActivityControl{},
+		&models.Event{},
+		&models.ScorecardTemplate{},
+		&models.ScorecardCriterion{},
+		&models.Evaluation{},
+		&models.EvaluationScore{},
+		&models.CandidateProfile{},
+		&models.ApplicationTransition{},
+		&models.Notification{},
 	); err != nil {
 		return nil, fmt.Errorf("failed to auto migrate: %w", err)
 	}
 
 	seedData(db)
+	backfillQueueOpenKeys(db)
+	backfillInterviewBusyKeys(db)
+	backfillApplications(db)
+
+	// Entries from before priority levels existed only had the high priority flag
+	db.Model(&models.QueueEntry{}).Where("is_high_priority = ? AND priority <> ?", true, models.PriorityHigh).
+		Update("priority", models.PriorityHigh)
 
 	log.Println("Database initialized successfully")
 	return db, nil
@@ -83,18 +103,96 @@ func seedData(db *gorm.DB) {
 		log.Println("Created default admin user (password: admin123)")
 	}
 
-	var activityCount int64
-	db.Model(&models.ActivityControl{}).Count(&activityCount)
-	if activityCount == 0 {
-		activity := models.ActivityControl{
-			ActiveQueueLimit:     6,
-			HighPriorityQuota:    2,
-			AverageInterviewTime: 8,
-			BufferTime:           5,
+	var eventCount int64
+	db.Model(&models.Event{}).Count(&eventCount)
+	if eventCount == 0 {
+		event := models.Event{
+			Name:                  "Recruitment Event",
+			ActiveQueueLimit:      6,
+			HighPriorityQuota:     2,
+			AverageInterviewTime:  8,
+			BufferTime:            5,
 			GroupInterviewMaxSize: 4,
-			Status:               "pending",
+			Status:                models.EventPending,
+		}
+
+		// Carry over the settings of the old single-activity table if present
+		if db.Migrator().HasTable("activity_controls") {
+			db.Table("activity_controls").
+				Select("start_time", "end_time", "status", "active_queue_limit", "high_priority_quota",
+					"average_interview_time", "buffer_time", "group_interview_max_size").
+				Order("id ASC").Limit(1).Scan(&event)
+		}
+		if event.StartTime.IsZero() {
+			event.StartTime = time.Now()
+			event.EndTime = event.StartTime.Add(8 * time.Hour)
+		}
+		event.Date = event.StartTime
+
+		db.Create(&event)
+		db.Model(&models.QueueEntry{}).Where("event_id = 0").Update("event_id", event.ID)
+		db.Model(&models.Interview{}).Where("event_id = 0").Update("event_id", event.ID)
+		db.Model(&models.GroupInterview{}).Where("event_id = 0").Update("event_id", event.ID)
+		log.Println("Created default recruitment event")
+	}
+}
+
+// backfillQueueOpenKeys sets the OpenKey of open queue entries created before
+// the column existed or before it included the event. If a candidate somehow
+// holds several open entries for one position in an event only the first
+// keeps the key, the rest stay unkeyed.
+func backfillQueueOpenKeys(db *gorm.DB) {
+	var entries []models.QueueEntry
+	db.Where("status IN ?", []string{"waiting", "called", "interviewing"}).
+		Order("id ASC").Find(&entries)
+
+	for _, entry := range entries {
+		key := models.QueueOpenKey(entry.EventID, entry.CandidateID, entry.PositionID)
+		if entry.OpenKey != nil && *entry.OpenKey == *key {
+			continue
+		}
+		if err := db.Model(&entry).UpdateColumn("open_key", key).Error; err != nil {
+			log.Printf("Queue entry %d left without open key: %v", entry.ID, err)
+		}
+	}
+}
+
+// backfillInterviewBusyKeys sets the busy keys of interviews that were in
+// progress before the columns existed. Where a candidate or interviewer is in
+// several at once only the oldest gets the key, the rest stay unkeyed.
+func backfillInterviewBusyKeys(db *gorm.DB) {
+	var interviews []models.Interview
+	db.Where("status = ? AND candidate_busy_key IS NULL", models.InterviewInProgress).
+		Order("id ASC").Find(&interviews)
+
+	interviewerKeyed := make(map[uint]bool)
+	for _, interview := range interviews {
+		if err := db.Model(&interview).UpdateColumn("candidate_busy_key", interview.CandidateID).Error; err != nil {
+			log.Printf("Interview %d left without candidate busy key: %v", interview.ID, err)
+		}
+
+		if interviewerKeyed[interview.InterviewerID] {
+			continue
 		}
-		db.Create(&activity)
-		log.Println("Created default activity control settings")
+		interviewerKeyed[interview.InterviewerID] = true
+		if err := db.Model(&interview).UpdateColumn("interviewer_busy_key", interview.InterviewerID).Error; err != nil {
+			log.Printf("Interview %d left without interviewer busy key: %v", interview.ID, err)
+		}
+	}
+}
+
+// backfillApplications opens an application for every candidate who queued for
+// a position before the pipeline existed, as interviewed where an interview
+// with them has already finished.
+func backfillApplications(db *gorm.DB) {
+	err := db.Exec(`INSERT IGNORE INTO candidate_positions (candidate_id, position_id, joined_at, status, created_at, updated_at)
+		SELECT q.candidate_id, q.position_id, MIN(q.join_time),
+			CASE WHEN EXISTS (SELECT 1 FROM interviews i WHERE i.candidate_id = q.candidate_id
+				AND i.position_id = q.position_id AND i.status = ?) THEN ? ELSE ? END,
+			NOW(), NOW()
+		FROM queue_entries q GROUP BY q.candidate_id, q.position_id`,
+		models.InterviewCompleted, models.ApplicationInterviewed, models.ApplicationQueued).Error
+	if err != nil {
+		log.Printf("Failed to backfill applications: %v", err)
 	}
-}
\ No newline at end of file
+}
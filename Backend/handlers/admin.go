@@ -3,30 +3,43 @@ package handlers
 import (
 	"errors"
 	"interview-system/models"
+	"interview-system/services"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AdminHandler struct {
-	db          *gorm.DB
-	redisClient *redis.Client
+	db            *gorm.DB
+	redisClient   *redis.Client
+	importService *services.ImportService
+	eventService  *services.EventService
+	policy        *services.ActivityPolicy
+	scheduler     *services.ActivityScheduler
 }
 
-func NewAdminHandler(db *gorm.DB, redisClient *redis.Client) *AdminHandler {
+// maxImportFileSize caps the size of an uploaded user import sheet.
+const maxImportFileSize = 10 << 20
+
+func NewAdminHandler(db *gorm.DB, redisClient *redis.Client, importService *services.ImportService, eventService *services.EventService, policy *services.ActivityPolicy, scheduler *services.ActivityScheduler) *AdminHandler {
 	return &AdminHandler{
-		db:          db,
-		redisClient: redisClient,
+		db:            db,
+		redisClient:   redisClient,
+		importService: importService,
+		eventService:  eventService,
+		policy:        policy,
+		scheduler:     scheduler,
 	}
 }
 
 func (h *AdminHandler) GetPublicActivityStatus(c *gin.Context) {
-	var activity models.ActivityControl
-	if err := h.db.First(&activity).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	activity, ok := h.resolveEvent(c)
+	if !ok {
 		return
 	}
 
@@ -35,7 +48,11 @@ func (h *AdminHandler) GetPublicActivityStatus(c *gin.Context) {
 	minutesUntilEnd := int(activity.EndTime.Sub(now).Minutes())
 
 	response := gin.H{
-		"is_active":            activity.Status == "active",
+		"event_id":             activity.ID,
+		"event_name":           activity.Name,
+		"venue":                activity.Venue,
+		"is_active":            activity.IsRunning(),
+		"phase":                activity.Status,
 		"start_time":           activity.StartTime,
 		"end_time":             activity.EndTime,
 		"current_time":         now,
@@ -43,29 +60,33 @@ func (h *AdminHandler) GetPublicActivityStatus(c *gin.Context) {
 		"is_ended":             now.After(activity.EndTime),
 		"minutes_until_start":  minutesUntilStart,
 		"minutes_until_end":    minutesUntilEnd,
-		"can_join_queue":       activity.Status == "active" && now.After(activity.StartTime) && now.Before(activity.EndTime),
+		"can_join_queue":       h.policy.Allow(activity, services.ActionJoinQueue) == nil,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
 func (h *AdminHandler) GetActivityControl(c *gin.Context) {
-	var activity models.ActivityControl
-	if err := h.db.First(&activity).Error; err != nil {
-		// If no record exists, create a default one
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			activity = models.ActivityControl{
-				Status:               "active",
-				ActiveQueueLimit:     6,
-				HighPriorityQuota:    2,
-				AverageInterviewTime: 8,
-				BufferTime:           5,
-				GroupInterviewMaxSize: 4,
-				StartTime:            time.Now(),
-				EndTime:              time.Now().Add(8 * time.Hour),
-			}
-			h.db.Create(&activity)
-		} else {
+	var activity *models.Event
+	var err error
+	if c.Query("event_id") != "" {
+		if activity, err = h.loadEvent(c); err != nil {
+			return
+		}
+	} else if activity, err = h.eventService.Current(); err != nil {
+		if !errors.Is(err, services.ErrNoOpenEvent) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		// If no event exists, create a default one
+		activity = &models.Event{
+			Name:      "Recruitment Event",
+			Status:    models.EventActive,
+			StartTime: time.Now(),
+			EndTime:   time.Now().Add(8 * time.Hour),
+		}
+		if err := h.eventService.Create(activity, nil); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 			return
 		}
@@ -74,12 +95,16 @@ func (h *AdminHandler) GetActivityControl(c *gin.Context) {
 	// Return with formatted times for frontend
 	response := gin.H{
 		"id":                        activity.ID,
+		"name":                      activity.Name,
+		"venue":                     activity.Venue,
+		"date":                      activity.Date,
 		"status":                    activity.Status,
 		"active_queue_limit":        activity.ActiveQueueLimit,
 		"high_priority_quota":       activity.HighPriorityQuota,
 		"average_interview_time":    activity.AverageInterviewTime,
 		"buffer_time":               activity.BufferTime,
 		"group_interview_max_size":  activity.GroupInterviewMaxSize,
+		"call_grace_period":         activity.CallGracePeriod,
 		"start_time":                activity.StartTime,
 		"end_time":                  activity.EndTime,
 		"activity_start_time":       activity.StartTime.Format("15:04"),
@@ -90,19 +115,20 @@ func (h *AdminHandler) GetActivityControl(c *gin.Context) {
 }
 
 func (h *AdminHandler) UpdateActivityControl(c *gin.Context) {
-	var activity models.ActivityControl
-	if err := h.db.First(&activity).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Activity control not found"})
+	activity, ok := h.resolveEvent(c)
+	if !ok {
 		return
 	}
 
+	// The phase follows the schedule and is changed through the start and end
+	// endpoints, never set here
 	var req struct {
 		ActiveQueueLimit      int    `json:"active_queue_limit"`
 		HighPriorityQuota     int    `json:"high_priority_quota"`
 		AverageInterviewTime  int    `json:"average_interview_time"`
 		BufferTime            int    `json:"buffer_time"`
 		GroupInterviewMaxSize int    `json:"group_interview_max_size"`
-		Status                string `json:"status"`
+		CallGracePeriod       int    `json:"call_grace_period"`
 		StartTime             string `json:"start_time"`
 		EndTime               string `json:"end_time"`
 	}
@@ -127,29 +153,32 @@ func (h *AdminHandler) UpdateActivityControl(c *gin.Context) {
 	if req.GroupInterviewMaxSize > 0 {
 		activity.GroupInterviewMaxSize = req.GroupInterviewMaxSize
 	}
-	if req.Status != "" {
-		activity.Status = req.Status
+	if req.CallGracePeriod > 0 {
+		activity.CallGracePeriod = req.CallGracePeriod
+	}
+	// Start and end times are given as HH:MM on the event's own date
+	day := activity.Date
+	if day.IsZero() {
+		day = time.Now()
 	}
 
 	// Handle start time
 	if req.StartTime != "" {
-		today := time.Now().Format("2006-01-02")
-		startTimeStr := today + " " + req.StartTime + ":00"
-		if startTime, err := time.Parse("2006-01-02 15:04:05", startTimeStr); err == nil {
+		startTimeStr := day.Format("2006-01-02") + " " + req.StartTime + ":00"
+		if startTime, err := time.ParseInLocation("2006-01-02 15:04:05", startTimeStr, time.Local); err == nil {
 			activity.StartTime = startTime
 		}
 	}
 
 	// Handle end time
 	if req.EndTime != "" {
-		today := time.Now().Format("2006-01-02")
-		endTimeStr := today + " " + req.EndTime + ":00"
-		if endTime, err := time.Parse("2006-01-02 15:04:05", endTimeStr); err == nil {
+		endTimeStr := day.Format("2006-01-02") + " " + req.EndTime + ":00"
+		if endTime, err := time.ParseInLocation("2006-01-02 15:04:05", endTimeStr, time.Local); err == nil {
 			activity.EndTime = endTime
 		}
 	}
 
-	if err := h.db.Save(&activity).Error; err != nil {
+	if err := h.db.Omit(clause.Associations, "status").Save(activity).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -158,40 +187,66 @@ func (h *AdminHandler) UpdateActivityControl(c *gin.Context) {
 }
 
 func (h *AdminHandler) StartActivity(c *gin.Context) {
-	var activity models.ActivityControl
-	if err := h.db.First(&activity).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Activity control not found"})
+	activity, ok := h.resolveEvent(c)
+	if !ok {
 		return
 	}
 
 	activity.StartTime = time.Now()
 	activity.EndTime = time.Now().Add(4 * time.Hour)
-	activity.Status = "active"
+	activity.Status = models.EventActive
 
-	if err := h.db.Save(&activity).Error; err != nil {
+	if err := h.db.Omit(clause.Associations).Save(activity).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Activity started successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Activity started successfully", "event_id": activity.ID})
 }
 
 func (h *AdminHandler) EndActivity(c *gin.Context) {
-	var activity models.ActivityControl
-	if err := h.db.First(&activity).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Activity control not found"})
+	activity, ok := h.resolveEvent(c)
+	if !ok {
 		return
 	}
 
-	activity.Status = "completed"
-	activity.EndTime = time.Now()
-
-	if err := h.db.Save(&activity).Error; err != nil {
+	if err := h.scheduler.End(activity); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Activity ended successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Activity ended successfully", "event_id": activity.ID})
+}
+
+// resolveEvent returns the event named by the optional event_id query
+// parameter, or the current event when none is given.
+func (h *AdminHandler) resolveEvent(c *gin.Context) (*models.Event, bool) {
+	if c.Query("event_id") != "" {
+		event, err := h.loadEvent(c)
+		return event, err == nil
+	}
+
+	event, err := h.eventService.Current()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Activity control not found"})
+		return nil, false
+	}
+	return event, true
+}
+
+func (h *AdminHandler) loadEvent(c *gin.Context) (*models.Event, error) {
+	id, err := strconv.ParseUint(c.Query("event_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return nil, err
+	}
+
+	event, err := h.eventService.Get(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return nil, err
+	}
+	return event, nil
 }
 
 func (h *AdminHandler) GetDashboard(c *gin.Context) {
@@ -247,7 +302,35 @@ func (h *AdminHandler) GetStatistics(c *gin.Context) {
 }
 
 func (h *AdminHandler) ImportUsers(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Import users endpoint"})
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file is too large"})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", c.PostForm("dry_run")))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	adminID, _ := c.Get("user_id")
+
+	report, err := h.importService.ImportUsers(adminID.(uint), fileHeader.Filename, file, dryRun)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report})
 }
 
 func (h *AdminHandler) GetSystemLogs(c *gin.Context) {
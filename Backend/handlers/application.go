@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"interview-system/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ApplicationHandler struct {
+	applicationService *services.ApplicationService
+}
+
+func NewApplicationHandler(applicationService *services.ApplicationService) *ApplicationHandler {
+	return &ApplicationHandler{applicationService: applicationService}
+}
+
+// GetMyApplications shows candidates where each of their applications stands.
+func (h *ApplicationHandler) GetMyApplications(c *gin.Context) {
+	candidateID, _ := c.Get("user_id")
+
+	applications, err := h.applicationService.ForCandidate(candidateID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"applications": applications})
+}
+
+// GetCompanyApplications lists the company's applications, filtered by the
+// optional position_id and status query parameters.
+func (h *ApplicationHandler) GetCompanyApplications(c *gin.Context) {
+	companyID, ok := currentCompanyID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Company admin is not linked to a company"})
+		return
+	}
+
+	positionID, ok := optionalPositionID(c)
+	if !ok {
+		return
+	}
+
+	applications, err := h.applicationService.ForCompany(companyID, positionID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"applications": applications})
+}
+
+// AdvanceApplication moves an application to another pipeline stage.
+func (h *ApplicationHandler) AdvanceApplication(c *gin.Context) {
+	companyID, ok := currentCompanyID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Company admin is not linked to a company"})
+		return
+	}
+
+	applicationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	application, err := h.applicationService.Advance(companyID, uint(applicationID), req.Status, req.Reason, userID.(uint))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrApplicationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidTransition), errors.Is(err, services.ErrReasonRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"application": application})
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"interview-system/models"
 	"interview-system/services"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,13 +23,14 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RegisterRequest is the public sign-up form. Only candidates sign up
+// themselves; Role may be omitted and is otherwise checked to be candidate.
 type RegisterRequest struct {
 	Account    string `json:"account" binding:"required"`
 	Password   string `json:"password" binding:"required,min=6"`
 	Name       string `json:"name" binding:"required"`
 	EmployeeID string `json:"employee_id"`
-	Role       string `json:"role" binding:"required"`
-	CompanyID  *uint  `json:"company_id"`
+	Role       string `json:"role"`
 	Email      string `json:"email"`
 	Phone      string `json:"phone"`
 }
@@ -45,23 +49,29 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, user, err := h.authService.Login(req.Account, req.Password)
+	tokens, user, err := h.authService.Login(req.Account, req.Password, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		var lockout *services.LockoutError
+		switch {
+		case errors.As(err, &lockout):
+			retryAfter := int(math.Ceil(time.Until(lockout.Until).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":        err.Error(),
+				"locked_until": lockout.Until,
+			})
+		case errors.Is(err, services.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	loginRecord := models.LoginRecord{
-		UserID:    user.ID,
-		IP:        c.ClientIP(),
-		UserAgent: c.Request.UserAgent(),
-		Status:    "success",
-		CreatedAt: time.Now(),
-	}
-	h.db.Create(&loginRecord)
-
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 		"user": gin.H{
 			"id":         user.ID,
 			"account":    user.Account,
@@ -80,13 +90,31 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if req.Role != "" {
+		role := models.UserRole(req.Role)
+		if !role.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+			return
+		}
+		if role != models.RoleCandidate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only candidates can register, other accounts are created by an administrator"})
+			return
+		}
+	}
+
+	var existing int64
+	h.db.Unscoped().Model(&models.User{}).Where("account = ?", req.Account).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account already exists"})
+		return
+	}
+
 	user := models.User{
 		Account:    req.Account,
 		Password:   req.Password,
 		Name:       req.Name,
 		EmployeeID: req.EmployeeID,
-		Role:       models.UserRole(req.Role),
-		CompanyID:  req.CompanyID,
+		Role:       models.RoleCandidate,
 		Email:      req.Email,
 		Phone:      req.Phone,
 		IsActive:   true,
@@ -125,6 +153,67 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	})
 }
 
+// Refresh exchanges a refresh token for a new access and refresh token.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(req.RefreshToken, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout ends the session the request's token belongs to.
 func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	sessionID := c.GetString("session_id")
+
+	// Tokens from before sessions existed can only be cut off per user
+	var err error
+	if sessionID != "" {
+		err = h.authService.RevokeSession(userID.(uint), sessionID)
+	} else {
+		err = h.authService.RevokeUser(userID.(uint))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// ForceLogout signs a user out of all of their sessions.
+func (h *AuthHandler) ForceLogout(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.authService.RevokeUser(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User signed out of all sessions"})
 }
\ No newline at end of file
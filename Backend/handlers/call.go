@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"interview-system/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CallHandler struct {
+	callService *services.CallService
+}
+
+func NewCallHandler(callService *services.CallService) *CallHandler {
+	return &CallHandler{callService: callService}
+}
+
+// CallNext calls the next available candidate across the interviewer's
+// positions, or for the position_id query parameter only.
+func (h *CallHandler) CallNext(c *gin.Context) {
+	positionID, ok := optionalPositionID(c)
+	if !ok {
+		return
+	}
+
+	interviewerID, _ := c.Get("user_id")
+
+	entry, err := h.callService.CallNext(interviewerID.(uint), positionID)
+	if err != nil {
+		respondCallError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"called": entry})
+}
+
+// MarkPresent checks in the candidate the interviewer called.
+func (h *CallHandler) MarkPresent(c *gin.Context) {
+	interviewerID, _ := c.Get("user_id")
+
+	entry, err := h.callService.MarkPresent(interviewerID.(uint))
+	if err != nil {
+		respondCallError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checked_in": entry})
+}
+
+// CheckIn lets a called candidate tell the interviewer they have arrived.
+func (h *CallHandler) CheckIn(c *gin.Context) {
+	var req struct {
+		PositionID uint `json:"position_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	candidateID, _ := c.Get("user_id")
+
+	entry, err := h.callService.CheckIn(candidateID.(uint), req.PositionID)
+	if err != nil {
+		respondCallError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checked_in": entry})
+}
+
+func respondCallError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNoAssignedPosition), errors.Is(err, services.ErrPositionNotAssigned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNoCandidateToCall), errors.Is(err, services.ErrNotCalled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInterviewerBusy), errors.Is(err, services.ErrCallPending),
+		errors.Is(err, services.ErrEventNotRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// optionalPositionID parses the position_id query parameter, 0 when absent.
+func optionalPositionID(c *gin.Context) (uint, bool) {
+	value := c.Query("position_id")
+	if value == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid position ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// currentCompanyID returns the company_id claim of the authenticated user.
+// The claim is a *uint, so a missing company comes through as a typed nil.
+func currentCompanyID(c *gin.Context) (uint, bool) {
+	value, exists := c.Get("company_id")
+	if !exists {
+		return 0, false
+	}
+
+	companyID, ok := value.(*uint)
+	if !ok || companyID == nil {
+		return 0, false
+	}
+	return *companyID, true
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"interview-system/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EvaluationHandler struct {
+	evaluationService *services.EvaluationService
+}
+
+func NewEvaluationHandler(evaluationService *services.EvaluationService) *EvaluationHandler {
+	return &EvaluationHandler{evaluationService: evaluationService}
+}
+
+// GetScorecard returns the scorecard template of one of the company's positions.
+func (h *EvaluationHandler) GetScorecard(c *gin.Context) {
+	companyID, positionID, ok := companyPositionParams(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.evaluationService.Template(companyID, positionID)
+	if err != nil {
+		respondEvaluationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scorecard": template})
+}
+
+// SaveScorecard creates or replaces the scorecard template of a position.
+func (h *EvaluationHandler) SaveScorecard(c *gin.Context) {
+	companyID, positionID, ok := companyPositionParams(c)
+	if !ok {
+		return
+	}
+
+	var req services.ScorecardInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.evaluationService.SaveTemplate(companyID, positionID, req)
+	if err != nil {
+		respondEvaluationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scorecard": template})
+}
+
+// ListPositionEvaluations returns every evaluation of a position with a
+// per-candidate comparison.
+func (h *EvaluationHandler) ListPositionEvaluations(c *gin.Context) {
+	companyID, positionID, ok := companyPositionParams(c)
+	if !ok {
+		return
+	}
+
+	evaluations, comparison, err := h.evaluationService.ForPosition(companyID, positionID)
+	if err != nil {
+		respondEvaluationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"evaluations": evaluations,
+		"comparison":  comparison,
+	})
+}
+
+// GetInterviewerScorecard returns the scorecard for the position_id query
+// parameter, which must be one of the interviewer's positions.
+func (h *EvaluationHandler) GetInterviewerScorecard(c *gin.Context) {
+	positionID, ok := optionalPositionID(c)
+	if !ok {
+		return
+	}
+	if positionID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "position_id is required"})
+		return
+	}
+
+	interviewerID, _ := c.Get("user_id")
+
+	template, err := h.evaluationService.InterviewerTemplate(interviewerID.(uint), positionID)
+	if err != nil {
+		respondEvaluationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scorecard": template})
+}
+
+// SubmitEvaluation evaluates an interview that was ended without one, such as
+// a group interview participant.
+func (h *EvaluationHandler) SubmitEvaluation(c *gin.Context) {
+	interviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview ID"})
+		return
+	}
+
+	var req services.EvaluationInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	interviewerID, _ := c.Get("user_id")
+
+	evaluation, err := h.evaluationService.Submit(interviewerID.(uint), uint(interviewID), req)
+	if err != nil {
+		respondEvaluationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"evaluation": evaluation})
+}
+
+// companyPositionParams reads the admin's company and the :id position.
+func companyPositionParams(c *gin.Context) (uint, uint, bool) {
+	companyID, ok := currentCompanyID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Company admin is not linked to a company"})
+		return 0, 0, false
+	}
+
+	positionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid position ID"})
+		return 0, 0, false
+	}
+	return companyID, uint(positionID), true
+}
+
+func respondEvaluationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPositionNotFound), errors.Is(err, services.ErrScorecardNotFound),
+		errors.Is(err, services.ErrInterviewNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotYourInterview), errors.Is(err, services.ErrNoAssignedPosition),
+		errors.Is(err, services.ErrPositionNotAssigned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidScorecard), errors.Is(err, services.ErrInvalidEvaluation),
+		errors.Is(err, services.ErrEvaluationRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadyEvaluated), errors.Is(err, services.ErrInterviewNotRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
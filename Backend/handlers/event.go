@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"interview-system/models"
+	"interview-system/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EventHandler struct {
+	eventService *services.EventService
+}
+
+type CreateEventRequest struct {
+	Name                  string    `json:"name" binding:"required"`
+	Date                  string    `json:"date"`
+	Venue                 string    `json:"venue"`
+	StartTime             time.Time `json:"start_time" binding:"required"`
+	EndTime               time.Time `json:"end_time" binding:"required"`
+	ActiveQueueLimit      int       `json:"active_queue_limit"`
+	HighPriorityQuota     int       `json:"high_priority_quota"`
+	AverageInterviewTime  int       `json:"average_interview_time"`
+	BufferTime            int       `json:"buffer_time"`
+	GroupInterviewMaxSize int       `json:"group_interview_max_size"`
+	CallGracePeriod       int       `json:"call_grace_period"`
+	PositionIDs           []uint    `json:"position_ids"`
+}
+
+type EventPositionsRequest struct {
+	PositionIDs []uint `json:"position_ids"`
+}
+
+func NewEventHandler(eventService *services.EventService) *EventHandler {
+	return &EventHandler{eventService: eventService}
+}
+
+// ListEvents lists events for control admins, who may include archived ones.
+func (h *EventHandler) ListEvents(c *gin.Context) {
+	includeArchived, _ := strconv.ParseBool(c.Query("include_archived"))
+	h.listEvents(c, includeArchived)
+}
+
+// ListPublicEvents is the unauthenticated event list; it never shows archived
+// events.
+func (h *EventHandler) ListPublicEvents(c *gin.Context) {
+	h.listEvents(c, false)
+}
+
+func (h *EventHandler) listEvents(c *gin.Context, includeArchived bool) {
+	events, err := h.eventService.List(includeArchived)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+func (h *EventHandler) GetEvent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := h.eventService.Get(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event": event})
+}
+
+func (h *EventHandler) CreateEvent(c *gin.Context) {
+	var req CreateEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.EndTime.After(req.StartTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be after start_time"})
+		return
+	}
+
+	event := models.Event{
+		Name:                  req.Name,
+		Venue:                 req.Venue,
+		StartTime:             req.StartTime,
+		EndTime:               req.EndTime,
+		ActiveQueueLimit:      req.ActiveQueueLimit,
+		HighPriorityQuota:     req.HighPriorityQuota,
+		AverageInterviewTime:  req.AverageInterviewTime,
+		BufferTime:            req.BufferTime,
+		GroupInterviewMaxSize: req.GroupInterviewMaxSize,
+		CallGracePeriod:       req.CallGracePeriod,
+	}
+
+	if req.Date != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be formatted as YYYY-MM-DD"})
+			return
+		}
+		event.Date = date
+	}
+
+	if err := h.eventService.Create(&event, req.PositionIDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"event": event})
+}
+
+func (h *EventHandler) SetEventPositions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req EventPositionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.eventService.SetPositions(uint(id), req.PositionIDs)
+	if err != nil {
+		if errors.Is(err, services.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event": event})
+}
+
+func (h *EventHandler) ArchiveEvent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := h.eventService.Archive(uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEventNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEventStillRunning):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event": event})
+}
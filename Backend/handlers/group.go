@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"interview-system/services"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GroupInterviewHandler struct {
+	groupService *services.GroupInterviewService
+}
+
+type InitiateGroupRequest struct {
+	PositionID      uint   `json:"position_id" binding:"required"`
+	MaxParticipants int    `json:"max_participants"`
+	CandidateIDs    []uint `json:"candidate_ids"`
+}
+
+type EndGroupRequest struct {
+	Notes   string                 `json:"notes"`
+	Results []services.GroupResult `json:"results"`
+}
+
+func NewGroupInterviewHandler(groupService *services.GroupInterviewService) *GroupInterviewHandler {
+	return &GroupInterviewHandler{groupService: groupService}
+}
+
+func (h *GroupInterviewHandler) InitiateGroupInterview(c *gin.Context) {
+	var req InitiateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	interviewerID, _ := c.Get("user_id")
+
+	group, err := h.groupService.Initiate(interviewerID.(uint), req.PositionID, req.MaxParticipants, req.CandidateIDs)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_interview": group})
+}
+
+func (h *GroupInterviewHandler) GetGroupInterview(c *gin.Context) {
+	groupID, ok := groupIDParam(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	group, err := h.groupService.Get(groupID, userID.(uint))
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_interview": group})
+}
+
+func (h *GroupInterviewHandler) StartGroupInterview(c *gin.Context) {
+	groupID, ok := groupIDParam(c)
+	if !ok {
+		return
+	}
+
+	interviewerID, _ := c.Get("user_id")
+
+	group, err := h.groupService.Start(groupID, interviewerID.(uint))
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_interview": group})
+}
+
+func (h *GroupInterviewHandler) EndGroupInterview(c *gin.Context) {
+	groupID, ok := groupIDParam(c)
+	if !ok {
+		return
+	}
+
+	// The notes are optional, so the body may be left out entirely
+	var req EndGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	interviewerID, _ := c.Get("user_id")
+
+	group, evaluations, err := h.groupService.End(groupID, interviewerID.(uint), req.Notes, req.Results)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_interview": group, "evaluations": evaluations})
+}
+
+// ListGroupInterviews lets control admins review group interviews, including
+// why the system formed the automatic ones.
+func (h *GroupInterviewHandler) ListGroupInterviews(c *gin.Context) {
+	var eventID uint
+	if value := c.Query("event_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+			return
+		}
+		eventID = uint(id)
+	}
+	autoOnly, _ := strconv.ParseBool(c.Query("auto"))
+
+	groups, err := h.groupService.List(eventID, autoOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_interviews": groups})
+}
+
+func (h *GroupInterviewHandler) GetMyInvitations(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	groups, err := h.groupService.PendingInvitations(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": groups})
+}
+
+func (h *GroupInterviewHandler) AcceptInvitation(c *gin.Context) {
+	h.respond(c, true)
+}
+
+func (h *GroupInterviewHandler) DeclineInvitation(c *gin.Context) {
+	h.respond(c, false)
+}
+
+func (h *GroupInterviewHandler) respond(c *gin.Context, accept bool) {
+	groupID, ok := groupIDParam(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	invitation, err := h.groupService.Respond(groupID, userID.(uint), accept)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitation": invitation})
+}
+
+func (h *GroupInterviewHandler) WithdrawFromGroup(c *gin.Context) {
+	groupID, ok := groupIDParam(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.groupService.Withdraw(groupID, userID.(uint)); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Withdrawn from group interview"})
+}
+
+func groupIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group interview ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondGroupError(c *gin.Context, err error) {
+	var conflict *services.InterviewConflictError
+	switch {
+	case errors.As(err, &conflict):
+		c.JSON(http.StatusConflict, gin.H{"error": conflict.Error(), "conflicting_interview": conflict.Interview})
+	case errors.Is(err, services.ErrGroupNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrGroupNotOwner), errors.Is(err, services.ErrGroupNotInvited),
+		errors.Is(err, services.ErrNoAssignedPosition), errors.Is(err, services.ErrPositionNotAssigned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrGroupWindowClosed),
+		errors.Is(err, services.ErrGroupAlreadyAnswered),
+		errors.Is(err, services.ErrGroupInvalidState),
+		errors.Is(err, services.ErrGroupNoParticipants),
+		errors.Is(err, services.ErrAlreadyEvaluated):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
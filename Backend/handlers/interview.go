@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"interview-system/models"
 	"interview-system/services"
+	"log"
 	"net/http"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,12 +15,31 @@ import (
 )
 
 type InterviewHandler struct {
-	db    *gorm.DB
-	wsHub *services.WebSocketHub
+	db                *gorm.DB
+	interviewService  *services.InterviewService
+	wsHub             *services.WebSocketHub
+	authService       *services.AuthService
+	invitationService *services.InvitationService
 }
 
-func NewInterviewHandler(db *gorm.DB, wsHub *services.WebSocketHub) *InterviewHandler {
-	return &InterviewHandler{db: db, wsHub: wsHub}
+type CreateInterviewerRequest struct {
+	Account    string `json:"account" binding:"required"`
+	Name       string `json:"name" binding:"required"`
+	EmployeeID string `json:"employee_id"`
+	Email      string `json:"email"`
+	Phone      string `json:"phone"`
+}
+
+type UpdateInterviewerRequest struct {
+	Name       string  `json:"name"`
+	EmployeeID *string `json:"employee_id"`
+	Email      *string `json:"email"`
+	Phone      *string `json:"phone"`
+	IsActive   *bool   `json:"is_active"`
+}
+
+func NewInterviewHandler(db *gorm.DB, interviewService *services.InterviewService, wsHub *services.WebSocketHub, authService *services.AuthService, invitationService *services.InvitationService) *InterviewHandler {
+	return &InterviewHandler{db: db, interviewService: interviewService, wsHub: wsHub, authService: authService, invitationService: invitationService}
 }
 
 func (h *InterviewHandler) GetInterviewQueue(c *gin.Context) {
@@ -29,69 +51,82 @@ func (h *InterviewHandler) GetInterviewQueue(c *gin.Context) {
 	var queue []models.QueueEntry
 
 	if len(assignments) == 0 {
-		// If interviewer has no assigned positions, show ALL waiting candidates
-		// Group by candidate to avoid duplicates when a candidate is in multiple position queues
-		h.db.Preload("Candidate").Preload("Position").
-			Where("status = ?", "waiting").
-			Order("is_high_priority DESC, priority_set_time ASC, join_time ASC").
-			Find(&queue)
-
-		// Deduplicate by candidate - keep only the highest priority entry per candidate
-		candidateMap := make(map[uint]models.QueueEntry)
-		for _, entry := range queue {
-			existing, exists := candidateMap[entry.CandidateID]
-			if !exists {
-				candidateMap[entry.CandidateID] = entry
-			} else {
-				// Keep the entry with higher priority or earlier join time
-				if entry.IsHighPriority && !existing.IsHighPriority {
-					candidateMap[entry.CandidateID] = entry
-				} else if entry.IsHighPriority == existing.IsHighPriority && entry.JoinTime.Before(existing.JoinTime) {
-					candidateMap[entry.CandidateID] = entry
-				}
-			}
-		}
-
-		// Convert map back to slice
-		dedupQueue := make([]models.QueueEntry, 0, len(candidateMap))
-		for _, entry := range candidateMap {
-			dedupQueue = append(dedupQueue, entry)
-		}
-
-		// Sort the deduplicated queue
-		sort.Slice(dedupQueue, func(i, j int) bool {
-			if dedupQueue[i].IsHighPriority != dedupQueue[j].IsHighPriority {
-				return dedupQueue[i].IsHighPriority
-			}
-			if dedupQueue[i].IsHighPriority && dedupQueue[j].IsHighPriority {
-				if dedupQueue[i].PrioritySetTime != nil && dedupQueue[j].PrioritySetTime != nil {
-					return dedupQueue[i].PrioritySetTime.Before(*dedupQueue[j].PrioritySetTime)
-				}
-			}
-			return dedupQueue[i].JoinTime.Before(dedupQueue[j].JoinTime)
-		})
-
+		// Candidates and their profiles are only shown for assigned positions
 		c.JSON(http.StatusOK, gin.H{
-			"queue": dedupQueue,
-			"message": "Showing all queues - no specific position assigned",
+			"queue":   []models.QueueEntry{},
+			"message": "No position assigned yet",
 		})
 		return
 	}
 
-	// Get queues for the first assigned position
-	positionID := assignments[0].PositionID
+	// Merge the queues of every assigned position and round, or only those of
+	// the position asked for
+	positionID, ok := optionalPositionID(c)
+	if !ok {
+		return
+	}
+	positionIDs, err := services.InterviewerPositions(h.db, interviewerID.(uint), positionID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	pool, err := services.InterviewerRoundPool(h.db, interviewerID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var waiting []models.QueueEntry
+	h.db.Preload("Candidate.Profile").Preload("Position").
+		Where("position_id IN ? AND status = ?", positionIDs, "waiting").
+		Order(models.QueueOrder).
+		Find(&waiting)
+
+	// Only the rounds the interviewer interviews for
+	queue = make([]models.QueueEntry, 0, len(waiting))
+	for _, entry := range waiting {
+		if pool.Covers(entry.PositionID, entry.Round) {
+			queue = append(queue, entry)
+		}
+	}
 
-	h.db.Preload("Candidate").Preload("Position").
-		Where("position_id = ? AND status = ?", positionID, "waiting").
-		Order("is_high_priority DESC, priority_set_time ASC, join_time ASC").
-		Find(&queue)
+	// Across positions, show candidates in the order the schedule expects to see them
+	sort.SliceStable(queue, func(i, j int) bool {
+		a, b := queue[i].EstimatedTime, queue[j].EstimatedTime
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.Before(*b)
+	})
+	h.markBusyCandidates(queue)
 
+	// position_id is the first position, as returned before interviewers
+	// could cover several
 	c.JSON(http.StatusOK, gin.H{
-		"queue": queue,
-		"position_id": positionID,
+		"queue":        queue,
+		"position_id":  positionIDs[0],
+		"position_ids": positionIDs,
 	})
 }
 
+// markBusyCandidates flags the entries whose candidate is in an interview or
+// has been called elsewhere, so the interviewer can skip them.
+func (h *InterviewHandler) markBusyCandidates(queue []models.QueueEntry) {
+	candidateIDs := make([]uint, len(queue))
+	for i := range queue {
+		candidateIDs[i] = queue[i].CandidateID
+	}
+
+	busy, err := services.BusyCandidates(h.db, candidateIDs)
+	if err != nil {
+		return
+	}
+	for i := range queue {
+		queue[i].BusyElsewhere = busy[queue[i].CandidateID]
+	}
+}
+
 func (h *InterviewHandler) StartInterview(c *gin.Context) {
 	var req struct {
 		CandidateID uint `json:"candidate_id" binding:"required"`
@@ -104,56 +139,40 @@ func (h *InterviewHandler) StartInterview(c *gin.Context) {
 	}
 
 	interviewerID, _ := c.Get("user_id")
-	now := time.Now()
-
-	// Validate that the position exists
-	var position models.Position
-	if err := h.db.First(&position, req.PositionID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid position ID"})
-		return
-	}
-
-	// Validate that the candidate exists
-	var candidate models.User
-	if err := h.db.First(&candidate, req.CandidateID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid candidate ID"})
-		return
-	}
 
-	interview := models.Interview{
-		CandidateID:   req.CandidateID,
-		InterviewerID: interviewerID.(uint),
-		PositionID:    req.PositionID,
-		Status:        models.InterviewInProgress,
-		StartTime:     &now,
-	}
-
-	if err := h.db.Create(&interview).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	interview, err := h.interviewService.Start(interviewerID.(uint), req.CandidateID, req.PositionID)
+	if err != nil {
+		var conflict *services.InterviewConflictError
+		switch {
+		case errors.As(err, &conflict):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":                 conflict.Error(),
+				"conflicting_interview": conflict.Interview,
+				"conflicting_call":      conflict.Call,
+			})
+		case errors.Is(err, services.ErrInvalidPosition), errors.Is(err, services.ErrInvalidCandidate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPositionNotAssigned):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNotInQueue):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEventNotRunning):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	h.db.Model(&models.QueueEntry{}).
-		Where("candidate_id = ? AND position_id = ?", req.CandidateID, req.PositionID).
-		Update("status", "interviewing")
-
-	message := services.Message{
-		Type: services.InterviewStatus,
-		Data: map[string]interface{}{
-			"interview_id": interview.ID,
-			"status":       "started",
-		},
-		Timestamp: time.Now(),
-	}
-	h.wsHub.BroadcastToUser(req.CandidateID, message)
-
 	c.JSON(http.StatusOK, gin.H{"interview": interview})
 }
 
 func (h *InterviewHandler) EndInterview(c *gin.Context) {
 	var req struct {
-		InterviewID uint   `json:"interview_id" binding:"required"`
-		Notes       string `json:"notes"`
+		InterviewID uint                      `json:"interview_id" binding:"required"`
+		Notes       string                    `json:"notes"`
+		Passed      *bool                     `json:"passed"`
+		Evaluation  *services.EvaluationInput `json:"evaluation"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -161,34 +180,25 @@ func (h *InterviewHandler) EndInterview(c *gin.Context) {
 		return
 	}
 
-	var interview models.Interview
-	if err := h.db.First(&interview, req.InterviewID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Interview not found"})
+	interviewerID, _ := c.Get("user_id")
+
+	_, evaluation, err := h.interviewService.End(interviewerID.(uint), req.InterviewID, req.Notes, req.Passed, req.Evaluation)
+	if err != nil {
+		respondEvaluationError(c, err)
 		return
 	}
 
-	now := time.Now()
-	duration := int(now.Sub(*interview.StartTime).Minutes())
-
-	interview.EndTime = &now
-	interview.Duration = duration
-	interview.Status = models.InterviewCompleted
-	interview.Notes = req.Notes
-
-	h.db.Save(&interview)
-
-	h.db.Model(&models.QueueEntry{}).
-		Where("candidate_id = ? AND position_id = ?", interview.CandidateID, interview.PositionID).
-		Update("status", "completed")
-
-	c.JSON(http.StatusOK, gin.H{"message": "Interview ended successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Interview ended successfully",
+		"evaluation": evaluation,
+	})
 }
 
 func (h *InterviewHandler) GetCurrentInterview(c *gin.Context) {
 	interviewerID, _ := c.Get("user_id")
 
 	var interview models.Interview
-	if err := h.db.Preload("Candidate").Preload("Position").
+	if err := h.db.Preload("Candidate.Profile").Preload("Position").
 		Where("interviewer_id = ? AND status = ?", interviewerID, models.InterviewInProgress).
 		First(&interview).Error; err != nil {
 		c.JSON(http.StatusOK, gin.H{"interview": nil})
@@ -198,47 +208,6 @@ func (h *InterviewHandler) GetCurrentInterview(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"interview": interview})
 }
 
-func (h *InterviewHandler) InitiateGroupInterview(c *gin.Context) {
-	var req struct {
-		PositionID      uint   `json:"position_id" binding:"required"`
-		MaxParticipants int    `json:"max_participants"`
-		CandidateIDs    []uint `json:"candidate_ids"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	interviewerID, _ := c.Get("user_id")
-
-	groupInterview := models.GroupInterview{
-		InterviewerID:   interviewerID.(uint),
-		PositionID:      req.PositionID,
-		MaxParticipants: req.MaxParticipants,
-		Status:          "inviting",
-	}
-
-	if err := h.db.Create(&groupInterview).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	for _, candidateID := range req.CandidateIDs {
-		message := services.Message{
-			Type: services.GroupInvitation,
-			Data: map[string]interface{}{
-				"group_interview_id": groupInterview.ID,
-				"position_id":        req.PositionID,
-			},
-			Timestamp: time.Now(),
-		}
-		h.wsHub.BroadcastToUser(candidateID, message)
-	}
-
-	c.JSON(http.StatusOK, gin.H{"group_interview": groupInterview})
-}
-
 func (h *InterviewHandler) GetInterviewerStats(c *gin.Context) {
 	interviewerID, _ := c.Get("user_id")
 
@@ -266,14 +235,14 @@ func (h *InterviewHandler) GetInterviewerStats(c *gin.Context) {
 }
 
 func (h *InterviewHandler) GetCompanyInterviewers(c *gin.Context) {
-	companyID, _ := c.Get("company_id")
+	companyID, ok := currentCompanyID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Company admin is not linked to a company"})
+		return
+	}
 
 	var interviewers []models.User
-	query := h.db.Where("role = ?", models.RoleInterviewer)
-
-	if companyID != nil {
-		query = query.Where("company_id = ?", companyID)
-	}
+	query := h.db.Where("role = ? AND company_id = ?", models.RoleInterviewer, companyID)
 
 	if err := query.Find(&interviewers).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -284,9 +253,159 @@ func (h *InterviewHandler) GetCompanyInterviewers(c *gin.Context) {
 }
 
 func (h *InterviewHandler) CreateInterviewer(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Create interviewer endpoint"})
+	companyID, ok := currentCompanyID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Company admin is not linked to a company"})
+		return
+	}
+
+	var req CreateInterviewerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID, _ := c.Get("user_id")
+
+	// The interviewer sets their own password through the invitation
+	interviewer, token, invitation, err := h.invitationService.Invite(adminID.(uint), services.InviteInput{
+		Account:    req.Account,
+		Name:       req.Name,
+		Role:       models.RoleInterviewer,
+		CompanyID:  &companyID,
+		EmployeeID: req.EmployeeID,
+		Email:      req.Email,
+		Phone:      req.Phone,
+	})
+	if err != nil {
+		respondInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"interviewer":      interviewer,
+		"invitation_token": token,
+		"expires_at":       invitation.ExpiresAt,
+	})
+}
+
+// ReissueInterviewerInvitation replaces the pending invitation of one of the
+// company's interviewers.
+func (h *InterviewHandler) ReissueInterviewerInvitation(c *gin.Context) {
+	interviewer, ok := h.loadCompanyInterviewer(c)
+	if !ok {
+		return
+	}
+
+	adminID, _ := c.Get("user_id")
+
+	token, invitation, err := h.invitationService.Reissue(adminID.(uint), interviewer.ID)
+	if err != nil {
+		respondInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitation_token": token,
+		"expires_at":       invitation.ExpiresAt,
+	})
 }
 
 func (h *InterviewHandler) UpdateInterviewer(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Update interviewer endpoint"})
+	interviewer, ok := h.loadCompanyInterviewer(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateInterviewerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != "" {
+		interviewer.Name = req.Name
+	}
+	if req.EmployeeID != nil {
+		interviewer.EmployeeID = *req.EmployeeID
+	}
+	if req.Email != nil {
+		interviewer.Email = *req.Email
+	}
+	if req.Phone != nil {
+		interviewer.Phone = *req.Phone
+	}
+	if req.IsActive != nil {
+		interviewer.IsActive = *req.IsActive
+	}
+
+	// Save skips zero values such as IsActive=false, so select the columns explicitly.
+	if err := h.db.Model(interviewer).
+		Select("name", "employee_id", "email", "phone", "is_active").
+		Updates(interviewer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A deactivated interviewer is signed out of every session right away
+	if req.IsActive != nil && !*req.IsActive {
+		if err := h.authService.RevokeUser(interviewer.ID); err != nil {
+			log.Printf("Failed to revoke sessions of interviewer %d: %v", interviewer.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"interviewer": interviewer})
+}
+
+// ResetInterviewerPassword signs one of the company's interviewers out and
+// returns a reset token they choose a new password with. The admin never
+// learns the password.
+func (h *InterviewHandler) ResetInterviewerPassword(c *gin.Context) {
+	interviewer, ok := h.loadCompanyInterviewer(c)
+	if !ok {
+		return
+	}
+
+	adminID, _ := c.Get("user_id")
+
+	token, invitation, err := h.invitationService.ResetPassword(adminID.(uint), interviewer.ID)
+	if err != nil {
+		respondInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reset_token": token,
+		"expires_at":  invitation.ExpiresAt,
+	})
+}
+
+// loadCompanyInterviewer fetches the interviewer named by the :id route param and
+// makes sure it belongs to the company admin's own company. It writes the error
+// response itself and returns false when the caller should stop.
+func (h *InterviewHandler) loadCompanyInterviewer(c *gin.Context) (*models.User, bool) {
+	companyID, ok := currentCompanyID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Company admin is not linked to a company"})
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interviewer ID"})
+		return nil, false
+	}
+
+	var interviewer models.User
+	if err := h.db.Where("role = ?", models.RoleInterviewer).First(&interviewer, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Interviewer not found"})
+		return nil, false
+	}
+
+	if interviewer.CompanyID == nil || *interviewer.CompanyID != companyID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Interviewer belongs to another company"})
+		return nil, false
+	}
+
+	return &interviewer, true
 }
\ No newline at end of file
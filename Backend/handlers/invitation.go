@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"interview-system/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationHandler struct {
+	invitationService *services.InvitationService
+}
+
+func NewInvitationHandler(invitationService *services.InvitationService) *InvitationHandler {
+	return &InvitationHandler{invitationService: invitationService}
+}
+
+// CreateUser lets a control admin create an interviewer or admin account of
+// any company. The response carries the invitation token the new user
+// needs to set their password.
+func (h *InvitationHandler) CreateUser(c *gin.Context) {
+	var req services.InviteInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID, _ := c.Get("user_id")
+
+	user, token, invitation, err := h.invitationService.Invite(adminID.(uint), req)
+	if err != nil {
+		respondInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"user":             user,
+		"invitation_token": token,
+		"expires_at":       invitation.ExpiresAt,
+	})
+}
+
+// ReissueInvitation replaces the pending invitation of any user.
+func (h *InvitationHandler) ReissueInvitation(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	adminID, _ := c.Get("user_id")
+
+	token, invitation, err := h.invitationService.Reissue(adminID.(uint), uint(userID))
+	if err != nil {
+		respondInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitation_token": token,
+		"expires_at":       invitation.ExpiresAt,
+	})
+}
+
+// GetInvitation shows who an invitation is for, before its password is set.
+func (h *InvitationHandler) GetInvitation(c *gin.Context) {
+	invitation, err := h.invitationService.Lookup(c.Param("token"))
+	if err != nil {
+		respondInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"account":    invitation.User.Account,
+		"name":       invitation.User.Name,
+		"role":       invitation.User.Role,
+		"company":    invitation.User.Company,
+		"expires_at": invitation.ExpiresAt,
+	})
+}
+
+// AcceptInvitation sets the invited user's password; they then log in as
+// usual.
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	var req struct {
+		Password string `json:"password" binding:"required,min=6"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.invitationService.Accept(c.Param("token"), req.Password)
+	if err != nil {
+		respondInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password set, you can now log in",
+		"account": user.Account,
+	})
+}
+
+func respondInvitationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidInvitee):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAccountExists), errors.Is(err, services.ErrNoPendingInvitation),
+		errors.Is(err, services.ErrInvitationPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidInvitation):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"interview-system/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LockoutHandler struct {
+	loginGuard *services.LoginGuard
+}
+
+func NewLockoutHandler(loginGuard *services.LoginGuard) *LockoutHandler {
+	return &LockoutHandler{loginGuard: loginGuard}
+}
+
+// ListLockouts returns the accounts and IPs currently locked out. With
+// all=true it also includes those with recent failed logins.
+func (h *LockoutHandler) ListLockouts(c *gin.Context) {
+	all, _ := strconv.ParseBool(c.Query("all"))
+
+	lockouts, err := h.loginGuard.Lockouts(all)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lockouts": lockouts})
+}
+
+// ClearLockout lets an account or IP log in again right away.
+func (h *LockoutHandler) ClearLockout(c *gin.Context) {
+	lockoutID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lockout ID"})
+		return
+	}
+
+	if err := h.loginGuard.Clear(uint(lockoutID)); err != nil {
+		if errors.Is(err, services.ErrLockoutNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Lockout cleared"})
+}
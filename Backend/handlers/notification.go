@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"interview-system/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// GetNotifications returns the user's latest notifications, only unread ones
+// with ?unread=true.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		if parsed < 200 {
+			limit = parsed
+		} else {
+			limit = 200
+		}
+	}
+
+	notifications, unread, err := h.notificationService.List(userID.(uint), unreadOnly, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unread":        unread,
+	})
+}
+
+// MarkNotificationRead marks one notification as read.
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+	h.markRead(c, uint(notificationID))
+}
+
+// MarkAllNotificationsRead marks every notification of the user as read.
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	h.markRead(c, 0)
+}
+
+func (h *NotificationHandler) markRead(c *gin.Context, notificationID uint) {
+	userID, _ := c.Get("user_id")
+
+	if err := h.notificationService.MarkRead(userID.(uint), notificationID); err != nil {
+		if errors.Is(err, services.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read"})
+}
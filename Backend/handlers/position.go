@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"interview-system/models"
 	"net/http"
 	"strconv"
@@ -8,6 +9,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// errRoundInUse aborts a rounds change that would drop a round still in use
+	errRoundInUse = errors.New("round in use")
+	// errNoSuchRound aborts an assignment to a round the position doesn't have
+	errNoSuchRound = errors.New("no such round")
 )
 
 type PositionHandler struct {
@@ -20,7 +29,18 @@ func NewPositionHandler(db *gorm.DB) *PositionHandler {
 
 func (h *PositionHandler) GetAvailablePositions(c *gin.Context) {
 	var positions []models.Position
-	if err := h.db.Preload("Company").Where("is_active = ?", true).Find(&positions).Error; err != nil {
+	query := h.db.Preload("Company").Where("is_active = ?", true)
+
+	// Narrow to one event's positions; an event without positions accepts all of them
+	if eventID := c.Query("event_id"); eventID != "" {
+		var linked int64
+		h.db.Table("event_positions").Where("event_id = ?", eventID).Count(&linked)
+		if linked > 0 {
+			query = query.Where("id IN (?)", h.db.Table("event_positions").Select("position_id").Where("event_id = ?", eventID))
+		}
+	}
+
+	if err := query.Find(&positions).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -32,7 +52,8 @@ func (h *PositionHandler) GetCompanyPositions(c *gin.Context) {
 	companyID, _ := c.Get("company_id")
 
 	var positions []models.Position
-	query := h.db.Preload("Company").Preload("Interviewers")
+	query := h.db.Preload("Company").Preload("Interviewers").
+		Preload("Rounds", func(db *gorm.DB) *gorm.DB { return db.Order("number ASC") })
 
 	if companyID != nil {
 		query = query.Where("company_id = ?", companyID)
@@ -131,14 +152,15 @@ func (h *PositionHandler) DeletePosition(c *gin.Context) {
 }
 
 func (h *PositionHandler) AssignInterviewer(c *gin.Context) {
-	positionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid position ID"})
+	position, ok := h.loadCompanyPosition(c)
+	if !ok {
 		return
 	}
 
 	var req struct {
 		InterviewerID uint `json:"interviewer_id" binding:"required"`
+		// Round limits the assignment to one round, 0 or absent for every round
+		Round int `json:"round"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -158,43 +180,59 @@ func (h *PositionHandler) AssignInterviewer(c *gin.Context) {
 		return
 	}
 
-	// Check if interviewer is already assigned to another position
-	var existingAssignment models.PositionInterviewer
-	if err := h.db.Where("interviewer_id = ?", req.InterviewerID).First(&existingAssignment).Error; err == nil {
-		// Interviewer is already assigned to a position
-		if existingAssignment.PositionID != uint(positionID) {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Interviewer is already assigned to another position. Please unassign them first.",
-				"current_position_id": existingAssignment.PositionID,
-			})
-			return
-		}
-		// Already assigned to this position
-		c.JSON(http.StatusOK, gin.H{"message": "Interviewer is already assigned to this position"})
+	if interviewer.CompanyID == nil || *interviewer.CompanyID != position.CompanyID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Interviewer belongs to another company"})
 		return
 	}
 
-	// Check if position exists
-	var position models.Position
-	if err := h.db.First(&position, positionID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Position not found"})
+	// Interviewers can cover several positions and rounds, but only once each
+	var existing int64
+	h.db.Model(&models.PositionInterviewer{}).
+		Where("position_id = ? AND interviewer_id = ? AND round = ?", position.ID, req.InterviewerID, req.Round).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Interviewer is already assigned to this position"})
 		return
 	}
 
-	// Create the assignment
+	// Create the assignment, checking the round under the position's row lock
+	// so SetRounds can't drop it meanwhile
 	assignment := models.PositionInterviewer{
-		PositionID:    uint(positionID),
+		PositionID:    position.ID,
 		InterviewerID: req.InterviewerID,
+		Round:         req.Round,
 		AssignedAt:    time.Now(),
 	}
 
-	if err := h.db.Create(&assignment).Error; err != nil {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var locked models.Position
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, position.ID).Error; err != nil {
+			return err
+		}
+
+		if req.Round != 0 {
+			var round int64
+			if err := tx.Model(&models.PositionRound{}).
+				Where("position_id = ? AND number = ?", position.ID, req.Round).Count(&round).Error; err != nil {
+				return err
+			}
+			if round == 0 {
+				return errNoSuchRound
+			}
+		}
+
+		return tx.Create(&assignment).Error
+	})
+	if errors.Is(err, errNoSuchRound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Position has no such round"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Also update the many-to-many relationship
-	h.db.Model(&position).Association("Interviewers").Append(&interviewer)
+	h.db.Model(position).Association("Interviewers").Append(&interviewer)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Interviewer assigned successfully",
@@ -203,14 +241,15 @@ func (h *PositionHandler) AssignInterviewer(c *gin.Context) {
 }
 
 func (h *PositionHandler) UnassignInterviewer(c *gin.Context) {
-	positionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid position ID"})
+	position, ok := h.loadCompanyPosition(c)
+	if !ok {
 		return
 	}
 
 	var req struct {
 		InterviewerID uint `json:"interviewer_id" binding:"required"`
+		// Round removes only the assignment to that round, absent removes all
+		Round *int `json:"round"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -219,25 +258,150 @@ func (h *PositionHandler) UnassignInterviewer(c *gin.Context) {
 	}
 
 	// Check if the assignment exists
-	var assignment models.PositionInterviewer
-	if err := h.db.Where("position_id = ? AND interviewer_id = ?",
-		positionID, req.InterviewerID).First(&assignment).Error; err != nil {
+	query := h.db.Where("position_id = ? AND interviewer_id = ?", position.ID, req.InterviewerID)
+	if req.Round != nil {
+		query = query.Where("round = ?", *req.Round)
+	}
+	var assignments []models.PositionInterviewer
+	if err := query.Find(&assignments).Error; err != nil || len(assignments) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
 		return
 	}
 
 	// Delete from PositionInterviewer table
-	if err := h.db.Delete(&assignment).Error; err != nil {
+	if err := h.db.Delete(&assignments).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Also remove from many-to-many relationship
-	var position models.Position
-	var interviewer models.User
-	h.db.First(&position, positionID)
-	h.db.First(&interviewer, req.InterviewerID)
-	h.db.Model(&position).Association("Interviewers").Delete(&interviewer)
+	// Also remove from many-to-many relationship once no round is left
+	var remaining int64
+	h.db.Model(&models.PositionInterviewer{}).
+		Where("position_id = ? AND interviewer_id = ?", position.ID, req.InterviewerID).Count(&remaining)
+	if remaining == 0 {
+		var interviewer models.User
+		h.db.First(&interviewer, req.InterviewerID)
+		h.db.Model(position).Association("Interviewers").Delete(&interviewer)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Interviewer unassigned successfully"})
-}
\ No newline at end of file
+}
+
+func (h *PositionHandler) GetRounds(c *gin.Context) {
+	position, ok := h.loadCompanyPosition(c)
+	if !ok {
+		return
+	}
+
+	var rounds []models.PositionRound
+	h.db.Where("position_id = ?", position.ID).Order("number ASC").Find(&rounds)
+
+	c.JSON(http.StatusOK, gin.H{"rounds": rounds})
+}
+
+// SetRounds replaces the position's rounds, numbering them in the order given.
+// Rounds that still have candidates queued or interviewers assigned can't be
+// dropped.
+func (h *PositionHandler) SetRounds(c *gin.Context) {
+	position, ok := h.loadCompanyPosition(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Rounds []struct {
+			Name        string `json:"name" binding:"required"`
+			Description string `json:"description"`
+		} `json:"rounds"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lastRound := len(req.Rounds)
+	if lastRound == 0 {
+		lastRound = 1
+	}
+
+	// The checks run under the position's row lock, so a candidate can't be
+	// queued for or an interviewer assigned to a round while it is dropped
+	var conflict string
+	rounds := make([]models.PositionRound, len(req.Rounds))
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var locked models.Position
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, position.ID).Error; err != nil {
+			return err
+		}
+
+		var queued int64
+		if err := tx.Model(&models.QueueEntry{}).
+			Where("position_id = ? AND round > ? AND status IN ?", position.ID, lastRound, []string{"waiting", "called", "interviewing"}).
+			Count(&queued).Error; err != nil {
+			return err
+		}
+		if queued > 0 {
+			conflict = "Candidates are still queued for a round being removed"
+			return errRoundInUse
+		}
+
+		var assigned int64
+		if err := tx.Model(&models.PositionInterviewer{}).
+			Where("position_id = ? AND round > ?", position.ID, lastRound).Count(&assigned).Error; err != nil {
+			return err
+		}
+		if assigned > 0 {
+			conflict = "Interviewers are still assigned to a round being removed"
+			return errRoundInUse
+		}
+
+		if err := tx.Where("position_id = ?", position.ID).Delete(&models.PositionRound{}).Error; err != nil {
+			return err
+		}
+		for i, round := range req.Rounds {
+			rounds[i] = models.PositionRound{
+				PositionID:  position.ID,
+				Number:      i + 1,
+				Name:        round.Name,
+				Description: round.Description,
+			}
+		}
+		if len(rounds) == 0 {
+			return nil
+		}
+		return tx.Create(&rounds).Error
+	})
+	if errors.Is(err, errRoundInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": conflict})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rounds": rounds})
+}
+
+// loadCompanyPosition loads the :id position if it belongs to the admin's company.
+func (h *PositionHandler) loadCompanyPosition(c *gin.Context) (*models.Position, bool) {
+	companyID, ok := currentCompanyID(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Company admin is not linked to a company"})
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid position ID"})
+		return nil, false
+	}
+
+	var position models.Position
+	if err := h.db.Where("id = ? AND company_id = ?", id, companyID).First(&position).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Position not found"})
+		return nil, false
+	}
+	return &position, true
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"interview-system/services"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileHandler struct {
+	profileService *services.ProfileService
+}
+
+func NewProfileHandler(profileService *services.ProfileService) *ProfileHandler {
+	return &ProfileHandler{profileService: profileService}
+}
+
+func (h *ProfileHandler) GetMyProfile(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	profile, err := h.profileService.Get(userID.(uint))
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+func (h *ProfileHandler) UpdateMyProfile(c *gin.Context) {
+	var req services.ProfileInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	profile, err := h.profileService.Update(userID.(uint), req)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+// UploadResume takes the PDF in the "resume" form field.
+func (h *ProfileHandler) UploadResume(c *gin.Context) {
+	fileHeader, err := c.FormFile("resume")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resume is required"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	userID, _ := c.Get("user_id")
+
+	profile, err := h.profileService.UploadResume(userID.(uint), fileHeader.Filename, fileHeader.Size, file)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+func (h *ProfileHandler) DownloadMyResume(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	file, profile, err := h.profileService.OpenResume(userID.(uint))
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+	serveResume(c, file, profile)
+}
+
+// GetCandidateProfile shows an interviewer the profile of a candidate in
+// one of their queues.
+func (h *ProfileHandler) GetCandidateProfile(c *gin.Context) {
+	candidateID, ok := candidateParam(c)
+	if !ok {
+		return
+	}
+
+	interviewerID, _ := c.Get("user_id")
+
+	profile, err := h.profileService.CandidateProfile(interviewerID.(uint), candidateID)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+func (h *ProfileHandler) DownloadCandidateResume(c *gin.Context) {
+	candidateID, ok := candidateParam(c)
+	if !ok {
+		return
+	}
+
+	interviewerID, _ := c.Get("user_id")
+
+	file, profile, err := h.profileService.CandidateResume(interviewerID.(uint), candidateID)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+	serveResume(c, file, profile)
+}
+
+func serveResume(c *gin.Context, file io.ReadCloser, profile *models.CandidateProfile) {
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, profile.ResumeSize, "application/pdf", file, map[string]string{
+		"Content-Disposition": fmt.Sprintf("inline; filename=%q", profile.ResumeFileName),
+	})
+}
+
+func candidateParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid candidate ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrResumeTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrResumeNotPDF), errors.Is(err, services.ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNoResume):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCandidateNotVisible):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
-	"interview-system/models"
+	"errors"
 	"interview-system/services"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -22,6 +24,10 @@ type SetPriorityRequest struct {
 	PositionID uint `json:"position_id" binding:"required"`
 }
 
+type JumpAheadRequest struct {
+	PositionID uint `json:"position_id" binding:"required"`
+}
+
 type DelayRequest struct {
 	Minutes int `json:"minutes" binding:"required,min=5,max=30"`
 }
@@ -44,7 +50,7 @@ func (h *QueueHandler) JoinQueue(c *gin.Context) {
 	candidateID := userID.(uint)
 
 	if err := h.queueService.JoinQueue(candidateID, req.PositionID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondQueueError(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -62,7 +68,7 @@ func (h *QueueHandler) SetHighPriority(c *gin.Context) {
 	candidateID := userID.(uint)
 
 	if err := h.queueService.SetHighPriority(candidateID, req.PositionID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondQueueError(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -94,16 +100,12 @@ func (h *QueueHandler) LeaveQueue(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	candidateID := userID.(uint)
 
-	result := h.db.Model(&models.QueueEntry{}).Where("candidate_id = ? AND position_id = ? AND status = ?",
-		candidateID, req.PositionID, "waiting").Update("status", "left")
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
-		return
-	}
-
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Not in queue for this position"})
+	if err := h.queueService.LeaveQueue(candidateID, req.PositionID); err != nil {
+		if errors.Is(err, services.ErrNotInQueue) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Not in queue for this position"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -121,27 +123,61 @@ func (h *QueueHandler) RequestDelay(c *gin.Context) {
 	candidateID := userID.(uint)
 
 	if err := h.queueService.ProcessDelay(candidateID, req.Minutes); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondQueueError(c, err, http.StatusInternalServerError)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Delay applied successfully"})
 }
 
+// CheckJumpAhead reports the jump ahead available for position_id, or the
+// best one across the candidate's queues. It doesn't change anything; the
+// candidate applies it with ConfirmJumpAhead.
 func (h *QueueHandler) CheckJumpAhead(c *gin.Context) {
-	positionID := c.Query("position_id")
-	if positionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "position_id required"})
+	userID, _ := c.Get("user_id")
+	candidateID := userID.(uint)
+
+	var pid uint
+	if positionID := c.Query("position_id"); positionID != "" {
+		pid = uint(atoi(positionID))
+	}
+
+	suggestion, err := h.queueService.JumpAheadSuggestion(candidateID, pid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if suggestion == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"available": false,
+			"message":   "No jump ahead available",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"available":  true,
+		"suggestion": suggestion,
+	})
+}
+
+// ConfirmJumpAhead applies a jump ahead. It can't be undone.
+func (h *QueueHandler) ConfirmJumpAhead(c *gin.Context) {
+	var req JumpAheadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	userID, _ := c.Get("user_id")
 	candidateID := userID.(uint)
 
-	var pid uint
-	pid = uint(atoi(positionID))
-
-	success, message := h.queueService.ProcessJumpAhead(candidateID, pid)
+	success, message, err := h.queueService.ProcessJumpAhead(candidateID, req.PositionID)
+	if err != nil {
+		respondQueueError(c, err, http.StatusInternalServerError)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": success,
@@ -167,8 +203,8 @@ func (h *QueueHandler) CheckConflicts(c *gin.Context) {
 		return
 	}
 
-	// If no optimization available, check and resolve conflicts
-	hasConflicts, messages := h.queueService.ResolveConflicts(candidateID)
+	// If no optimization available, report the conflicts the schedule worked around
+	hasConflicts, messages := h.queueService.Conflicts(candidateID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"has_conflicts": hasConflicts,
@@ -211,7 +247,7 @@ func (h *QueueHandler) ApplyQueueOptimization(c *gin.Context) {
 	}
 
 	if err := h.queueService.ApplyQueueOptimization(candidateID, req.RegularPositionID, req.PriorityPositionID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondQueueError(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -221,6 +257,101 @@ func (h *QueueHandler) ApplyQueueOptimization(c *gin.Context) {
 	})
 }
 
+// GetQueueHistory lists the changes to the candidate's places in queues, so
+// they can see why an interview moved.
+func (h *QueueHandler) GetQueueHistory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	candidateID := userID.(uint)
+
+	history, err := h.queueService.History(candidateID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+// ListQueueOptimizations lets control admins search the queue audit trail by
+// event, candidate, position, type, result and a from/to window (RFC 3339).
+func (h *QueueHandler) ListQueueOptimizations(c *gin.Context) {
+	filter := services.OptimizationFilter{
+		Type:   c.Query("type"),
+		Result: c.Query("result"),
+		Limit:  200,
+	}
+
+	ids := map[string]*uint{
+		"event_id":     &filter.EventID,
+		"candidate_id": &filter.CandidateID,
+		"position_id":  &filter.PositionID,
+	}
+	for name, target := range ids {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+			return
+		}
+		*target = uint(id)
+	}
+
+	times := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, target := range times {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " time, use RFC 3339"})
+			return
+		}
+		*target = &t
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	records, err := h.queueService.ListOptimizations(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"optimizations": records})
+}
+
+// respondQueueError writes err to the response. Activity policy errors get
+// their own status and a machine-readable code so the frontend can tell
+// "too early" from "too late"; anything else uses the fallback status.
+func respondQueueError(c *gin.Context, err error, fallback int) {
+	switch {
+	case errors.Is(err, services.ErrActivityNotStarted):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "activity_not_started"})
+	case errors.Is(err, services.ErrActivityEnded):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "activity_ended"})
+	case errors.Is(err, services.ErrJoinClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "join_closed"})
+	case errors.Is(err, services.ErrPriorityClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "priority_closed"})
+	default:
+		c.JSON(fallback, gin.H{"error": err.Error()})
+	}
+}
+
 func atoi(s string) int {
 	var n int
 	for _, ch := range s {
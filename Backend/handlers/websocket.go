@@ -48,16 +48,17 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 	}
 
 	client := &services.Client{
-		ID:     uuid.New().String(),
-		UserID: claims.UserID,
-		Role:   string(claims.Role),
-		Conn:   conn,
-		Send:   make(chan []byte, 256),
-		Hub:    h.hub,
+		ID:        uuid.New().String(),
+		UserID:    claims.UserID,
+		Role:      string(claims.Role),
+		SessionID: claims.ID,
+		Conn:      conn,
+		Send:      make(chan []byte, 256),
+		Hub:       h.hub,
 	}
 
 	h.hub.Register(client)
 
 	go client.WritePump()
 	go client.ReadPump()
-}
\ No newline at end of file
+}
@@ -21,15 +21,26 @@ func main() {
 
 	redisClient := database.InitializeRedis(cfg.Redis)
 
+	queueCache := services.NewQueueCache(redisClient, db)
+	queueCache.Warm()
+
 	wsHub := services.NewWebSocketHub()
 	go wsHub.Run()
 
+	activityScheduler := services.NewActivityScheduler(db, wsHub, queueCache, nil)
+	go activityScheduler.Run()
+
 	r := gin.Default()
+	// Lockouts are keyed on ClientIP, so only trust forwarded headers from
+	// known proxies
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
 
 	r.Use(middleware.CORS())
 	r.Use(middleware.RequestLogger())
 
-	routes.SetupRoutes(r, db, redisClient, wsHub)
+	routes.SetupRoutes(r, db, redisClient, queueCache, wsHub, activityScheduler)
 
 	log.Printf("Server starting on port %s", cfg.Server.Port)
 	if err := r.Run(":" + cfg.Server.Port); err != nil {
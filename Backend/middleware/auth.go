@@ -37,6 +37,7 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 		c.Set("name", claims.Name)
 		c.Set("role", claims.Role)
 		c.Set("company_id", claims.CompanyID)
+		c.Set("session_id", claims.ID)
 
 		c.Next()
 	}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// Application stages of a candidate for one position, stored in
+// CandidatePosition.Status.
+const (
+	ApplicationQueued      = "queued"
+	ApplicationInterviewed = "interviewed"
+	ApplicationShortlisted = "shortlisted"
+	ApplicationSecondRound = "second_round"
+	ApplicationOffer       = "offer"
+	ApplicationAccepted    = "accepted"
+	ApplicationRejected    = "rejected"
+)
+
+// applicationTransitions lists the stages each stage may move on to. Accepted
+// and rejected are final.
+var applicationTransitions = map[string][]string{
+	ApplicationQueued:      {ApplicationInterviewed, ApplicationRejected},
+	ApplicationInterviewed: {ApplicationShortlisted, ApplicationRejected},
+	ApplicationShortlisted: {ApplicationSecondRound, ApplicationOffer, ApplicationRejected},
+	ApplicationSecondRound: {ApplicationOffer, ApplicationRejected},
+	ApplicationOffer:       {ApplicationAccepted, ApplicationRejected},
+}
+
+// CanTransitionApplication reports whether an application may move from one
+// stage to the other.
+func CanTransitionApplication(from, to string) bool {
+	for _, next := range applicationTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplicationTransition records one stage change of an application.
+type ApplicationTransition struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	ApplicationID uint   `gorm:"not null;index" json:"application_id"`
+	FromStatus    string `json:"from_status"`
+	ToStatus      string `gorm:"not null" json:"to_status"`
+	Reason        string `json:"reason"`
+	// ActorID is nil when the system made the change, such as after an interview
+	ActorID   *uint     `json:"actor_id"`
+	ActorRole string    `json:"actor_role"`
+	CreatedAt time.Time `json:"created_at"`
+}
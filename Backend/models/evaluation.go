@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+// Interview recommendations.
+const (
+	RecommendationHire   = "hire"
+	RecommendationNoHire = "no_hire"
+)
+
+// ScorecardTemplate is the structured evaluation form a company uses for one
+// of its positions. Every criterion is scored from 1 to Scale.
+type ScorecardTemplate struct {
+	ID         uint                 `gorm:"primaryKey" json:"id"`
+	PositionID uint                 `gorm:"not null;uniqueIndex" json:"position_id"`
+	CompanyID  uint                 `gorm:"not null;index" json:"company_id"`
+	Name       string               `json:"name"`
+	Scale      int                  `gorm:"not null" json:"scale"`
+	Criteria   []ScorecardCriterion `gorm:"foreignKey:TemplateID" json:"criteria"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+type ScorecardCriterion struct {
+	ID              uint    `gorm:"primaryKey" json:"id"`
+	TemplateID      uint    `gorm:"not null;index" json:"template_id"`
+	Name            string  `gorm:"not null" json:"name"`
+	Description     string  `json:"description"`
+	Weight          float64 `gorm:"not null" json:"weight"`
+	CommentRequired bool    `json:"comment_required"`
+	SortOrder       int     `json:"sort_order"`
+}
+
+// Evaluation is an interviewer's structured verdict on one interview. It is
+// only ever shown to the company that owns the position.
+type Evaluation struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	InterviewID    uint   `gorm:"not null;uniqueIndex" json:"interview_id"`
+	CandidateID    uint   `gorm:"not null;index" json:"candidate_id"`
+	Candidate      User   `gorm:"foreignKey:CandidateID" json:"candidate,omitempty"`
+	InterviewerID  uint   `gorm:"not null" json:"interviewer_id"`
+	Interviewer    User   `gorm:"foreignKey:InterviewerID" json:"interviewer,omitempty"`
+	PositionID     uint   `gorm:"not null;index" json:"position_id"`
+	CompanyID      uint   `gorm:"not null;index" json:"company_id"`
+	TemplateID     *uint  `json:"template_id"`
+	Recommendation string `gorm:"not null" json:"recommendation"`
+	// OverallScore is the weighted average of the criterion scores, on the
+	// template's scale
+	OverallScore float64           `json:"overall_score"`
+	Comments     string            `json:"comments"`
+	Scores       []EvaluationScore `gorm:"foreignKey:EvaluationID" json:"scores"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// EvaluationScore keeps the criterion's name and weight as they were when it
+// was scored, so editing the template later doesn't rewrite past evaluations.
+type EvaluationScore struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	EvaluationID  uint    `gorm:"not null;index" json:"evaluation_id"`
+	CriterionID   uint    `json:"criterion_id"`
+	CriterionName string  `json:"criterion_name"`
+	Weight        float64 `json:"weight"`
+	Score         int     `json:"score"`
+	Comment       string  `json:"comment"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// Event phases, in the order an event moves through them. Closing starts
+// ten minutes before the end and blocks new queue joins; final call starts
+// five minutes before the end.
+const (
+	EventPending   = "pending"
+	EventActive    = "active"
+	EventClosing   = "closing"
+	EventFinalCall = "final_call"
+	EventCompleted = "completed"
+)
+
+// Event is a single recruitment day or job fair. Each event carries its own
+// schedule and queue parameters, and queues, interviews and group interviews
+// are tied to the event they happened in.
+type Event struct {
+	ID                    uint      `gorm:"primaryKey" json:"id"`
+	Name                  string    `gorm:"not null" json:"name"`
+	Date                  time.Time `gorm:"type:date" json:"date"`
+	Venue                 string    `json:"venue"`
+	StartTime             time.Time `json:"start_time"`
+	EndTime               time.Time `json:"end_time"`
+	Status                string    `gorm:"index" json:"status"`
+	ActiveQueueLimit      int       `json:"active_queue_limit"`
+	HighPriorityQuota     int       `json:"high_priority_quota"`
+	AverageInterviewTime  int       `json:"average_interview_time"`
+	BufferTime            int       `json:"buffer_time"`
+	GroupInterviewMaxSize int       `json:"group_interview_max_size"`
+	// CallGracePeriod is how many minutes a called candidate has to turn up
+	// before the next candidate is called
+	CallGracePeriod int        `gorm:"default:3" json:"call_grace_period"`
+	Positions       []Position `gorm:"many2many:event_positions" json:"positions,omitempty"`
+	ArchivedAt      *time.Time `gorm:"index" json:"archived_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// IsRunning reports whether the event is between its start and its end.
+func (e *Event) IsRunning() bool {
+	return e.Status == EventActive || e.Status == EventClosing || e.Status == EventFinalCall
+}
+
+// IsArchived reports whether the event has been archived by an admin.
+func (e *Event) IsArchived() bool {
+	return e.ArchivedAt != nil
+}
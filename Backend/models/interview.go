@@ -15,37 +15,92 @@ const (
 	InterviewCancelled  InterviewStatus = "cancelled"
 )
 
+const (
+	GroupInviting   = "inviting"
+	GroupReady      = "ready"
+	GroupInProgress = "in_progress"
+	GroupCompleted  = "completed"
+	GroupCancelled  = "cancelled"
+)
+
+// Group invitation statuses. Accepted candidates who miss the participant cap
+// are waitlisted and can be promoted if a selected candidate withdraws.
+const (
+	InvitationPending    = "invited"
+	InvitationAccepted   = "accepted"
+	InvitationDeclined   = "declined"
+	InvitationExpired    = "expired"
+	InvitationSelected   = "selected"
+	InvitationWaitlisted = "waitlisted"
+	InvitationWithdrawn  = "withdrawn"
+)
+
 type Interview struct {
 	ID            uint            `gorm:"primaryKey" json:"id"`
+	EventID       uint            `gorm:"index" json:"event_id"`
 	CandidateID   uint            `gorm:"not null" json:"candidate_id"`
 	Candidate     User            `gorm:"foreignKey:CandidateID" json:"candidate,omitempty"`
 	InterviewerID uint            `gorm:"not null" json:"interviewer_id"`
 	Interviewer   User            `gorm:"foreignKey:InterviewerID" json:"interviewer,omitempty"`
 	PositionID    uint            `gorm:"not null" json:"position_id"`
 	Position      Position        `gorm:"foreignKey:PositionID" json:"position,omitempty"`
+	Round         int             `gorm:"not null;default:1" json:"round"`
 	Status        InterviewStatus `gorm:"not null" json:"status"`
 	StartTime     *time.Time      `json:"start_time"`
 	EndTime       *time.Time      `json:"end_time"`
 	Duration      int             `json:"duration"`
 	IsGroupInterview bool         `json:"is_group_interview"`
+	GroupInterviewID *uint        `gorm:"index" json:"group_interview_id,omitempty"`
 	Notes         string          `json:"notes"`
+	// Passed is the interviewer's verdict, nil when none was given. Passing a
+	// round queues the candidate for the position's next round
+	Passed        *bool           `json:"passed"`
+	// CandidateBusyKey and InterviewerBusyKey hold the candidate and
+	// interviewer IDs while the interview is in progress and are cleared when
+	// it ends, so unique indexes allow each of them only one running interview.
+	// In a group interview only the first participant's row holds the
+	// interviewer key.
+	CandidateBusyKey   *uint      `gorm:"uniqueIndex" json:"-"`
+	InterviewerBusyKey *uint      `gorm:"uniqueIndex" json:"-"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt  `gorm:"index" json:"-"`
 }
 
+// ReleaseInterviewKeys returns the column updates that free an interview's
+// busy keys once it is no longer in progress.
+func ReleaseInterviewKeys() map[string]interface{} {
+	return map[string]interface{}{"candidate_busy_key": nil, "interviewer_busy_key": nil}
+}
+
 type GroupInterview struct {
 	ID            uint           `gorm:"primaryKey" json:"id"`
+	EventID       uint           `gorm:"index" json:"event_id"`
 	InterviewerID uint           `gorm:"not null" json:"interviewer_id"`
 	Interviewer   User           `gorm:"foreignKey:InterviewerID" json:"interviewer,omitempty"`
 	PositionID    uint           `gorm:"not null" json:"position_id"`
 	Position      Position       `gorm:"foreignKey:PositionID" json:"position,omitempty"`
 	MaxParticipants int          `json:"max_participants"`
 	Participants  []User         `gorm:"many2many:group_interview_participants" json:"participants,omitempty"`
+	Invitations   []GroupInterviewInvitation `gorm:"foreignKey:GroupInterviewID" json:"invitations,omitempty"`
 	Status        string         `json:"status"`
+	ResponseDeadline *time.Time  `json:"response_deadline"`
+	AutoTriggered bool           `json:"auto_triggered"`
+	TriggerReason string         `json:"trigger_reason"`
 	StartTime     *time.Time     `json:"start_time"`
 	EndTime       *time.Time     `json:"end_time"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
-}
\ No newline at end of file
+}
+
+type GroupInterviewInvitation struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	GroupInterviewID uint       `gorm:"not null;uniqueIndex:idx_group_invitation" json:"group_interview_id"`
+	CandidateID      uint       `gorm:"not null;uniqueIndex:idx_group_invitation" json:"candidate_id"`
+	Candidate        User       `gorm:"foreignKey:CandidateID" json:"candidate,omitempty"`
+	Status           string     `gorm:"not null" json:"status"`
+	RespondedAt      *time.Time `json:"responded_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification is a message kept for a user so they can read it after the
+// live WebSocket push has gone by.
+type Notification struct {
+	ID        uint                   `gorm:"primaryKey" json:"id"`
+	UserID    uint                   `gorm:"not null;index" json:"user_id"`
+	Type      string                 `gorm:"not null" json:"type"`
+	Title     string                 `json:"title"`
+	Message   string                 `gorm:"type:text" json:"message"`
+	Data      map[string]interface{} `gorm:"serializer:json" json:"data"`
+	ReadAt    *time.Time             `json:"read_at"`
+	CreatedAt time.Time              `gorm:"index" json:"created_at"`
+}
@@ -7,38 +7,60 @@ import (
 )
 
 type Position struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	Name         string         `gorm:"not null" json:"name"`
-	CompanyID    uint           `gorm:"not null" json:"company_id"`
-	Company      Company        `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
-	Description  string         `json:"description"`
-	IsActive     bool           `gorm:"default:true" json:"is_active"`
-	Interviewers []User         `gorm:"many2many:position_interviewers" json:"interviewers,omitempty"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	Name         string          `gorm:"not null" json:"name"`
+	CompanyID    uint            `gorm:"not null" json:"company_id"`
+	Company      Company         `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Description  string          `json:"description"`
+	IsActive     bool            `gorm:"default:true" json:"is_active"`
+	Interviewers []User          `gorm:"many2many:position_interviewers" json:"interviewers,omitempty"`
+	Rounds       []PositionRound `gorm:"foreignKey:PositionID" json:"rounds,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt  `gorm:"index" json:"-"`
+}
+
+// PositionRound is one interview round of a position, numbered from 1.
+// Candidates who pass a round queue for the next one. A position without
+// rounds has a single, unnamed round 1.
+type PositionRound struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PositionID  uint      `gorm:"not null;uniqueIndex:idx_position_round" json:"position_id"`
+	Number      int       `gorm:"not null;uniqueIndex:idx_position_round" json:"number"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type PositionInterviewer struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	PositionID   uint      `gorm:"not null" json:"position_id"`
-	Position     Position  `gorm:"foreignKey:PositionID" json:"-"`
+	ID            uint     `gorm:"primaryKey" json:"id"`
+	PositionID    uint     `gorm:"not null" json:"position_id"`
+	Position      Position `gorm:"foreignKey:PositionID" json:"-"`
 	InterviewerID uint     `gorm:"not null" json:"interviewer_id"`
-	Interviewer  User      `gorm:"foreignKey:InterviewerID" json:"interviewer,omitempty"`
-	AssignedAt   time.Time `json:"assigned_at"`
+	Interviewer   User     `gorm:"foreignKey:InterviewerID" json:"interviewer,omitempty"`
+	// Round limits the assignment to one round of the position, 0 covers
+	// every round
+	Round      int       `gorm:"not null;default:0" json:"round"`
+	AssignedAt time.Time `json:"assigned_at"`
 }
 
+// CandidatePosition is a candidate's application for a position. Status is
+// the pipeline stage, one of the Application* constants.
 type CandidatePosition struct {
 	ID             uint       `gorm:"primaryKey" json:"id"`
-	CandidateID    uint       `gorm:"not null" json:"candidate_id"`
+	CandidateID    uint       `gorm:"not null;uniqueIndex:idx_candidate_position" json:"candidate_id"`
 	Candidate      User       `gorm:"foreignKey:CandidateID" json:"candidate,omitempty"`
-	PositionID     uint       `gorm:"not null" json:"position_id"`
+	PositionID     uint       `gorm:"not null;uniqueIndex:idx_candidate_position" json:"position_id"`
 	Position       Position   `gorm:"foreignKey:PositionID" json:"position,omitempty"`
 	QueuePosition  int        `json:"queue_position"`
 	IsHighPriority bool       `json:"is_high_priority"`
 	PrioritySetAt  *time.Time `json:"priority_set_at"`
 	JoinedAt       time.Time  `json:"joined_at"`
-	Status         string     `json:"status"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
-}
\ No newline at end of file
+	Status         string     `gorm:"default:queued" json:"status"`
+	// StatusReason is the reason given for the latest stage change
+	StatusReason string                  `json:"status_reason"`
+	Transitions  []ApplicationTransition `gorm:"foreignKey:ApplicationID" json:"transitions,omitempty"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// CandidateProfile is the background a candidate shares with the interviewers
+// of the positions they queue for.
+type CandidateProfile struct {
+	ID                uint     `gorm:"primaryKey" json:"id"`
+	UserID            uint     `gorm:"not null;uniqueIndex" json:"user_id"`
+	Education         string   `gorm:"type:text" json:"education"`
+	Skills            []string `gorm:"serializer:json" json:"skills"`
+	YearsOfExperience int      `json:"years_of_experience"`
+	Links             []string `gorm:"serializer:json" json:"links"`
+	// ResumeKey locates the uploaded PDF in blob storage, empty when there is none
+	ResumeKey        string     `json:"-"`
+	ResumeFileName   string     `json:"resume_file_name"`
+	ResumeSize       int64      `json:"resume_size"`
+	ResumeUploadedAt *time.Time `json:"resume_uploaded_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// HasResume reports whether the candidate has uploaded a resume.
+func (p *CandidateProfile) HasResume() bool {
+	return p.ResumeKey != ""
+}
@@ -1,48 +1,111 @@
 package models
 
 import (
+	"fmt"
 	"time"
 )
 
 type QueueEntry struct {
 	ID               uint      `gorm:"primaryKey" json:"id"`
+	EventID          uint      `gorm:"index" json:"event_id"`
 	CandidateID      uint      `gorm:"not null" json:"candidate_id"`
 	Candidate        User      `gorm:"foreignKey:CandidateID" json:"candidate,omitempty"`
 	PositionID       uint      `gorm:"not null" json:"position_id"`
 	Position         Position  `gorm:"foreignKey:PositionID" json:"position,omitempty"`
+	// Round is the position's interview round the entry queues for. Each
+	// round of a position is a queue of its own
+	Round            int       `gorm:"not null;default:1" json:"round"`
 	QueuePosition    int       `json:"queue_position"`
 	IsHighPriority   bool      `json:"is_high_priority"`
+	Priority         int       `gorm:"default:1;index" json:"priority"`
+	// TimeSaved is how many minutes a jump ahead saved, used to order entries
+	// within the jump ahead level
+	TimeSaved        int       `json:"time_saved"`
 	PrioritySetTime  *time.Time `json:"priority_set_time"`
 	JoinTime         time.Time `json:"join_time"`
 	EstimatedTime    *time.Time `json:"estimated_time"`
+	ConflictDelayed  bool      `json:"conflict_delayed"`
 	IsActive         bool      `json:"is_active"`
 	Status           string    `json:"status"`
 	JumpAheadUsed    bool      `json:"jump_ahead_used"`
 	DelayUsed        int       `json:"delay_used"`
+	// CalledAt and CalledBy are set while the entry is "called": an
+	// interviewer has asked the candidate to come over and is waiting for them.
+	// CheckedInAt is set once the candidate has turned up
+	CalledAt         *time.Time `json:"called_at"`
+	CalledBy         *uint     `json:"called_by"`
+	CheckedInAt      *time.Time `json:"checked_in_at"`
+	// NoShowCount is how many calls the candidate missed on this entry
+	NoShowCount      int       `json:"no_show_count"`
+	// OpenKey is set while the entry is open and cleared once it is closed, so the
+	// unique index allows only one open entry per candidate and position in an
+	// event
+	OpenKey          *string   `gorm:"size:64;uniqueIndex" json:"-"`
+	// BusyElsewhere is filled in for interviewers' queue views when the
+	// candidate is being interviewed or has been called for another position
+	BusyElsewhere    bool      `gorm:"-" json:"busy_elsewhere"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
 }
 
-type QueueOptimization struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	CandidateID uint      `gorm:"not null" json:"candidate_id"`
-	Candidate   User      `gorm:"foreignKey:CandidateID" json:"-"`
-	Type        string    `json:"type"`
-	Details     string    `json:"details"`
-	Result      string    `json:"result"`
-	CreatedAt   time.Time `json:"created_at"`
+// Queue priority levels, higher levels are served first.
+const (
+	PriorityRegular   = 1
+	PriorityJumpAhead = 2
+	PriorityHigh      = 3
+)
+
+// QueueOrder is the order waiting entries are served in: by priority level,
+// jump ahead entries by the time they save, then by when priority was set and
+// when the candidate joined.
+const QueueOrder = "priority DESC, time_saved DESC, priority_set_time ASC, join_time ASC"
+
+// QueueEntryLess reports whether a is served before b, matching QueueOrder.
+func QueueEntryLess(a, b *QueueEntry) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if a.TimeSaved != b.TimeSaved {
+		return a.TimeSaved > b.TimeSaved
+	}
+	if a.PrioritySetTime != nil && b.PrioritySetTime != nil && !a.PrioritySetTime.Equal(*b.PrioritySetTime) {
+		return a.PrioritySetTime.Before(*b.PrioritySetTime)
+	}
+	return a.JoinTime.Before(b.JoinTime)
+}
+
+// QueueOpenKey is the OpenKey of an open entry for the candidate and position
+// in the event.
+func QueueOpenKey(eventID, candidateID, positionID uint) *string {
+	key := fmt.Sprintf("%d:%d:%d", eventID, candidateID, positionID)
+	return &key
+}
+
+// CloseQueueEntry returns the column updates that close an entry with the
+// given status and release its OpenKey.
+func CloseQueueEntry(status string) map[string]interface{} {
+	return map[string]interface{}{"status": status, "open_key": nil}
 }
 
-type ActivityControl struct {
-	ID                   uint      `gorm:"primaryKey" json:"id"`
-	StartTime            time.Time `json:"start_time"`
-	EndTime              time.Time `json:"end_time"`
-	Status               string    `json:"status"`
-	ActiveQueueLimit     int       `json:"active_queue_limit"`
-	HighPriorityQuota    int       `json:"high_priority_quota"`
-	AverageInterviewTime int       `json:"average_interview_time"`
-	BufferTime           int       `json:"buffer_time"`
-	GroupInterviewMaxSize int      `json:"group_interview_max_size"`
-	CreatedAt            time.Time `json:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at"`
-}
\ No newline at end of file
+// QueueOptimization is the audit trail of changes to a candidate's place in
+// a queue: jump aheads, queue optimizations, delays, conflict reschedules and
+// no-shows.
+// TimeSaved is in minutes and negative when the interview moved later.
+type QueueOptimization struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	EventID     uint       `gorm:"index" json:"event_id"`
+	CandidateID uint       `gorm:"not null;index" json:"candidate_id"`
+	Candidate   User       `gorm:"foreignKey:CandidateID" json:"-"`
+	PositionID  uint       `gorm:"index" json:"position_id"`
+	Position    Position   `gorm:"foreignKey:PositionID" json:"position,omitempty"`
+	Type        string     `gorm:"index" json:"type"`
+	Details     string     `json:"details"`
+	Result      string     `json:"result"`
+	BeforeETA   *time.Time `json:"before_eta"`
+	AfterETA    *time.Time `json:"after_eta"`
+	TimeSaved   int        `json:"time_saved"`
+	ActorID     *uint      `json:"actor_id"`
+	ActorRole   string     `json:"actor_role"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	CreatedAt   time.Time  `gorm:"index" json:"created_at"`
+}
@@ -15,6 +15,15 @@ const (
 	RoleCompanyAdmin   UserRole = "company_admin"
 )
 
+// Valid reports whether r is one of the known roles.
+func (r UserRole) Valid() bool {
+	switch r {
+	case RoleCandidate, RoleInterviewer, RoleControlAdmin, RoleCompanyAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
 	ID           uint           `gorm:"primaryKey" json:"id"`
 	Account      string         `gorm:"uniqueIndex;not null" json:"account"`
@@ -27,6 +36,10 @@ type User struct {
 	Email        string         `json:"email"`
 	Phone        string         `json:"phone"`
 	IsActive     bool           `gorm:"default:true" json:"is_active"`
+	// NoShowCount is how many interview calls the candidate has missed over
+	// all events. Demotion and removal go by the misses in the current event
+	NoShowCount  int            `json:"no_show_count"`
+	Profile      *CandidateProfile `gorm:"foreignKey:UserID" json:"profile,omitempty"`
 	LastLogin    *time.Time     `json:"last_login"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
@@ -43,12 +56,77 @@ type Company struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// Login record statuses. Locked attempts were refused without checking the
+// password because the account or IP was locked out.
+const (
+	LoginSuccess = "success"
+	LoginFailed  = "failed"
+	LoginLocked  = "locked"
+)
+
+// LoginRecord is one login attempt. UserID is nil when the account doesn't
+// exist; Account keeps what was typed either way.
 type LoginRecord struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `json:"user_id"`
-	User      User      `gorm:"foreignKey:UserID" json:"-"`
-	IP        string    `json:"ip"`
+	UserID    *uint     `gorm:"index" json:"user_id"`
+	User      *User     `gorm:"foreignKey:UserID" json:"-"`
+	Account   string    `gorm:"size:191;index" json:"account"`
+	IP        string    `gorm:"size:64;index" json:"ip"`
 	UserAgent string    `json:"user_agent"`
 	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-}
\ No newline at end of file
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// Lockout kinds.
+const (
+	LockoutAccount = "account"
+	LockoutIP      = "ip"
+)
+
+// LoginLockout counts recent failed logins of one account or client IP.
+// Whenever Failures reaches the limit the key is locked out, each time for
+// twice as long as the last; Lockouts is how many times that has happened.
+type LoginLockout struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Kind          string     `gorm:"size:16;not null;uniqueIndex:idx_lockout_key" json:"kind"`
+	Value         string     `gorm:"size:191;not null;uniqueIndex:idx_lockout_key" json:"value"`
+	Failures      int        `json:"failures"`
+	Lockouts      int        `json:"lockouts"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// RefreshToken is one link in a login session's chain of refresh tokens. Each
+// use swaps it for a new one; presenting a used token again means it leaked,
+// and the whole session is revoked.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	SessionID string     `gorm:"size:36;not null;index" json:"session_id"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"user_agent"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Invitation lets a company admin or interviewer created by an administrator
+// choose their own password. Only the token's hash is stored; the account
+// can't sign in until the invitation is accepted.
+type Invitation struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    uint   `gorm:"not null;index" json:"user_id"`
+	User      User   `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	TokenHash string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	CreatedBy uint   `json:"created_by"`
+	// Reset marks a password reset of an existing account. Accepting it sets
+	// the password but leaves the account's active flag alone
+	Reset      bool       `json:"reset"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -11,24 +11,55 @@ import (
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(r *gin.Engine, db *gorm.DB, redisClient *redis.Client, wsHub *services.WebSocketHub) {
+func SetupRoutes(r *gin.Engine, db *gorm.DB, redisClient *redis.Client, queueCache *services.QueueCache, wsHub *services.WebSocketHub, activityScheduler *services.ActivityScheduler) {
 	cfg := config.Load()
 
-	authService := services.NewAuthService(db, &cfg.JWT)
-	queueService := services.NewQueueService(db, wsHub)
+	notificationService := services.NewNotificationService(db, wsHub)
+	loginGuard := services.NewLoginGuard(db, notificationService, nil)
+	authService := services.NewAuthService(db, &cfg.JWT, services.NewRevocationList(redisClient), wsHub, loginGuard)
+	eventService := services.NewEventService(db, cfg.Queue)
+	activityPolicy := services.NewActivityPolicy(nil)
+	scheduleEngine := services.NewScheduleEngine(db, wsHub, nil)
+	go scheduleEngine.Run()
+	admission := services.NewAdmission(db, wsHub)
+	applicationService := services.NewApplicationService(db, notificationService)
+	queueService := services.NewQueueService(db, wsHub, eventService, activityPolicy, queueCache, scheduleEngine, admission, applicationService)
+	interviewService := services.NewInterviewService(db, wsHub, eventService, queueCache, scheduleEngine, admission, applicationService)
+	evaluationService := services.NewEvaluationService(db)
+	profileService := services.NewProfileService(db, services.NewLocalBlobStore(cfg.Storage.ResumeDir), cfg.Storage.MaxResumeSize)
+	invitationService := services.NewInvitationService(db, authService)
+	importService := services.NewImportService(db, authService, invitationService)
+	groupService := services.NewGroupInterviewService(db, wsHub, eventService, queueCache, scheduleEngine, admission, applicationService, nil)
+	groupService.ResumePending()
+	callService := services.NewCallService(db, wsHub, eventService, queueCache, scheduleEngine, admission, nil)
+	callService.ResumePending()
+	go services.NewGroupTrigger(db, groupService, nil).Run()
 
 	authHandler := handlers.NewAuthHandler(authService, db)
 	queueHandler := handlers.NewQueueHandler(queueService, db)
 	positionHandler := handlers.NewPositionHandler(db)
-	interviewHandler := handlers.NewInterviewHandler(db, wsHub)
-	adminHandler := handlers.NewAdminHandler(db, redisClient)
+	interviewHandler := handlers.NewInterviewHandler(db, interviewService, wsHub, authService, invitationService)
+	adminHandler := handlers.NewAdminHandler(db, redisClient, importService, eventService, activityPolicy, activityScheduler)
+	eventHandler := handlers.NewEventHandler(eventService)
+	groupHandler := handlers.NewGroupInterviewHandler(groupService)
+	callHandler := handlers.NewCallHandler(callService)
+	evaluationHandler := handlers.NewEvaluationHandler(evaluationService)
+	profileHandler := handlers.NewProfileHandler(profileService)
+	applicationHandler := handlers.NewApplicationHandler(applicationService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	lockoutHandler := handlers.NewLockoutHandler(loginGuard)
 	wsHandler := handlers.NewWebSocketHandler(wsHub, authService)
 
 	api := r.Group("/api")
 	{
 		api.POST("/login", authHandler.Login)
 		api.POST("/register", authHandler.Register)
+		api.POST("/refresh", authHandler.Refresh)
+		api.GET("/invitations/:token", invitationHandler.GetInvitation)
+		api.POST("/invitations/:token/accept", invitationHandler.AcceptInvitation)
 		api.GET("/activity/status", adminHandler.GetPublicActivityStatus)
+		api.GET("/events", eventHandler.ListPublicEvents)
 
 		api.GET("/ws", wsHandler.HandleWebSocket)
 
@@ -37,30 +68,54 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, redisClient *redis.Client, wsHub *s
 		{
 			authenticated.GET("/profile", authHandler.GetProfile)
 			authenticated.POST("/logout", authHandler.Logout)
+			authenticated.GET("/notifications", notificationHandler.GetNotifications)
+			authenticated.POST("/notifications/read", notificationHandler.MarkAllNotificationsRead)
+			authenticated.POST("/notifications/:id/read", notificationHandler.MarkNotificationRead)
 
 			candidate := authenticated.Group("/candidate")
 			candidate.Use(middleware.RoleMiddleware("candidate"))
 			{
 				candidate.GET("/positions", positionHandler.GetAvailablePositions)
+				candidate.GET("/profile", profileHandler.GetMyProfile)
+				candidate.PUT("/profile", profileHandler.UpdateMyProfile)
+				candidate.POST("/profile/resume", profileHandler.UploadResume)
+				candidate.GET("/profile/resume", profileHandler.DownloadMyResume)
+				candidate.GET("/applications", applicationHandler.GetMyApplications)
 				candidate.POST("/queue/join", queueHandler.JoinQueue)
 				candidate.POST("/queue/priority", queueHandler.SetHighPriority)
 				candidate.GET("/queue/status", queueHandler.GetMyQueues)
 				candidate.POST("/queue/leave", queueHandler.LeaveQueue)
 				candidate.POST("/queue/delay", queueHandler.RequestDelay)
 				candidate.GET("/queue/jumpahead", queueHandler.CheckJumpAhead)
+				candidate.POST("/queue/jumpahead", queueHandler.ConfirmJumpAhead)
 				candidate.GET("/queue/conflicts", queueHandler.CheckConflicts)
 				candidate.GET("/queue/optimization", queueHandler.CheckQueueOptimization)
 				candidate.POST("/queue/optimize", queueHandler.ApplyQueueOptimization)
+				candidate.GET("/queue/history", queueHandler.GetQueueHistory)
+				candidate.POST("/queue/checkin", callHandler.CheckIn)
+				candidate.GET("/group/invitations", groupHandler.GetMyInvitations)
+				candidate.POST("/group/:id/accept", groupHandler.AcceptInvitation)
+				candidate.POST("/group/:id/decline", groupHandler.DeclineInvitation)
+				candidate.POST("/group/:id/withdraw", groupHandler.WithdrawFromGroup)
 			}
 
 			interviewer := authenticated.Group("/interviewer")
 			interviewer.Use(middleware.RoleMiddleware("interviewer"))
 			{
 				interviewer.GET("/queue", interviewHandler.GetInterviewQueue)
+				interviewer.POST("/next", callHandler.CallNext)
+				interviewer.POST("/present", callHandler.MarkPresent)
 				interviewer.POST("/interview/start", interviewHandler.StartInterview)
 				interviewer.POST("/interview/end", interviewHandler.EndInterview)
 				interviewer.GET("/interview/current", interviewHandler.GetCurrentInterview)
-				interviewer.POST("/group/initiate", interviewHandler.InitiateGroupInterview)
+				interviewer.POST("/interview/:id/evaluation", evaluationHandler.SubmitEvaluation)
+				interviewer.GET("/scorecard", evaluationHandler.GetInterviewerScorecard)
+				interviewer.GET("/candidates/:id/profile", profileHandler.GetCandidateProfile)
+				interviewer.GET("/candidates/:id/resume", profileHandler.DownloadCandidateResume)
+				interviewer.POST("/group/initiate", groupHandler.InitiateGroupInterview)
+				interviewer.GET("/group/:id", groupHandler.GetGroupInterview)
+				interviewer.POST("/group/:id/start", groupHandler.StartGroupInterview)
+				interviewer.POST("/group/:id/end", groupHandler.EndGroupInterview)
 				interviewer.GET("/stats", interviewHandler.GetInterviewerStats)
 			}
 
@@ -71,10 +126,22 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, redisClient *redis.Client, wsHub *s
 				controlAdmin.PUT("/activity", adminHandler.UpdateActivityControl)
 				controlAdmin.POST("/activity/start", adminHandler.StartActivity)
 				controlAdmin.POST("/activity/end", adminHandler.EndActivity)
+				controlAdmin.GET("/events", eventHandler.ListEvents)
+				controlAdmin.POST("/events", eventHandler.CreateEvent)
+				controlAdmin.GET("/events/:id", eventHandler.GetEvent)
+				controlAdmin.PUT("/events/:id/positions", eventHandler.SetEventPositions)
+				controlAdmin.POST("/events/:id/archive", eventHandler.ArchiveEvent)
 				controlAdmin.GET("/dashboard", adminHandler.GetDashboard)
 				controlAdmin.GET("/stats", adminHandler.GetStatistics)
+				controlAdmin.POST("/users", invitationHandler.CreateUser)
 				controlAdmin.POST("/users/import", adminHandler.ImportUsers)
+				controlAdmin.POST("/users/:id/invitation", invitationHandler.ReissueInvitation)
+				controlAdmin.POST("/users/:id/logout", authHandler.ForceLogout)
 				controlAdmin.GET("/logs", adminHandler.GetSystemLogs)
+				controlAdmin.GET("/lockouts", lockoutHandler.ListLockouts)
+				controlAdmin.DELETE("/lockouts/:id", lockoutHandler.ClearLockout)
+				controlAdmin.GET("/group-interviews", groupHandler.ListGroupInterviews)
+				controlAdmin.GET("/queue/optimizations", queueHandler.ListQueueOptimizations)
 			}
 
 			companyAdmin := authenticated.Group("/company")
@@ -87,8 +154,17 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, redisClient *redis.Client, wsHub *s
 				companyAdmin.GET("/interviewers", interviewHandler.GetCompanyInterviewers)
 				companyAdmin.POST("/interviewers", interviewHandler.CreateInterviewer)
 				companyAdmin.PUT("/interviewers/:id", interviewHandler.UpdateInterviewer)
+				companyAdmin.POST("/interviewers/:id/password-reset", interviewHandler.ResetInterviewerPassword)
+				companyAdmin.POST("/interviewers/:id/invitation", interviewHandler.ReissueInterviewerInvitation)
 				companyAdmin.POST("/positions/:id/assign", positionHandler.AssignInterviewer)
 				companyAdmin.POST("/positions/:id/unassign", positionHandler.UnassignInterviewer)
+				companyAdmin.GET("/positions/:id/rounds", positionHandler.GetRounds)
+				companyAdmin.PUT("/positions/:id/rounds", positionHandler.SetRounds)
+				companyAdmin.GET("/positions/:id/scorecard", evaluationHandler.GetScorecard)
+				companyAdmin.PUT("/positions/:id/scorecard", evaluationHandler.SaveScorecard)
+				companyAdmin.GET("/positions/:id/evaluations", evaluationHandler.ListPositionEvaluations)
+				companyAdmin.GET("/applications", applicationHandler.GetCompanyApplications)
+				companyAdmin.POST("/applications/:id/advance", applicationHandler.AdvanceApplication)
 				companyAdmin.GET("/candidates", adminHandler.GetCompanyCandidates)
 				companyAdmin.GET("/stats", adminHandler.GetCompanyStats)
 			}
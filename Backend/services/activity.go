@@ -0,0 +1,208 @@
+package services
+
+import (
+	"fmt"
+	"interview-system/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	closingWindow   = 10 * time.Minute
+	finalCallWindow = 5 * time.Minute
+)
+
+// Clock tells the scheduler what time it is. Production code uses the wall
+// clock; tests can substitute a fixed or manually advanced clock.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ActivityScheduler moves events through their phases as time passes:
+// pending -> active -> closing (10 min left) -> final_call (5 min left) -> completed.
+type ActivityScheduler struct {
+	db       *gorm.DB
+	wsHub    *WebSocketHub
+	cache    *QueueCache
+	clock    Clock
+	interval time.Duration
+}
+
+func NewActivityScheduler(db *gorm.DB, wsHub *WebSocketHub, cache *QueueCache, clock Clock) *ActivityScheduler {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &ActivityScheduler{
+		db:       db,
+		wsHub:    wsHub,
+		cache:    cache,
+		clock:    clock,
+		interval: 15 * time.Second,
+	}
+}
+
+// PhaseAt returns the phase an event should be in at the given time.
+// Completed is terminal, so an event an admin ended early stays ended.
+func PhaseAt(event *models.Event, now time.Time) string {
+	if event.Status == models.EventCompleted {
+		return models.EventCompleted
+	}
+
+	switch remaining := event.EndTime.Sub(now); {
+	case now.Before(event.StartTime):
+		return models.EventPending
+	case remaining <= 0:
+		return models.EventCompleted
+	case remaining <= finalCallWindow:
+		return models.EventFinalCall
+	case remaining <= closingWindow:
+		return models.EventClosing
+	default:
+		return models.EventActive
+	}
+}
+
+func (s *ActivityScheduler) Run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Tick()
+	for range ticker.C {
+		s.Tick()
+	}
+}
+
+// Tick checks every live event once and applies any due phase transition.
+func (s *ActivityScheduler) Tick() {
+	var events []models.Event
+	if err := s.db.Where("archived_at IS NULL AND status <> ?", models.EventCompleted).
+		Find(&events).Error; err != nil {
+		log.Printf("Activity scheduler: failed to load events: %v", err)
+		return
+	}
+
+	now := s.clock.Now()
+	for i := range events {
+		event := &events[i]
+		if phase := PhaseAt(event, now); phase != event.Status {
+			if _, err := s.transition(event, phase, now); err != nil {
+				log.Printf("Activity scheduler: failed to move event %d to %s: %v", event.ID, phase, err)
+			}
+		}
+	}
+}
+
+// End completes the event now, e.g. when an admin stops it early. It goes
+// through the same transition as a scheduled end, so waiting and called
+// entries expire and everyone is told the event is over. Ending an event that
+// has already ended only expires any entries it left behind.
+func (s *ActivityScheduler) End(event *models.Event) error {
+	now := s.clock.Now()
+	for {
+		if err := s.db.First(event, event.ID).Error; err != nil {
+			return err
+		}
+		if event.Status == models.EventCompleted {
+			s.expireWaitingEntries(event.ID)
+			return nil
+		}
+
+		if err := s.db.Model(&models.Event{}).Where("id = ?", event.ID).Update("end_time", now).Error; err != nil {
+			return err
+		}
+		event.EndTime = now
+
+		// Retry if the scheduler moved the event to another phase meanwhile
+		moved, err := s.transition(event, models.EventCompleted, now)
+		if err != nil || moved {
+			return err
+		}
+	}
+}
+
+// transition moves the event from its current status to phase and announces
+// it. It reports false without changing anything if the status was changed by
+// someone else in the meantime.
+func (s *ActivityScheduler) transition(event *models.Event, phase string, now time.Time) (bool, error) {
+	previous := event.Status
+
+	// Only move the event if nobody else changed its status in the meantime
+	result := s.db.Model(&models.Event{}).
+		Where("id = ? AND status = ?", event.ID, previous).
+		Update("status", phase)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	event.Status = phase
+
+	expired := int64(0)
+	if phase == models.EventCompleted {
+		expired = s.expireWaitingEntries(event.ID)
+	}
+
+	log.Printf("Event %d moved from %s to %s", event.ID, previous, phase)
+
+	minutesRemaining := int(event.EndTime.Sub(now).Minutes())
+	if minutesRemaining < 0 {
+		minutesRemaining = 0
+	}
+
+	s.wsHub.BroadcastToAll(Message{
+		Type: TimeWarning,
+		Data: map[string]interface{}{
+			"event_id":          event.ID,
+			"phase":             phase,
+			"previous_phase":    previous,
+			"minutes_remaining": minutesRemaining,
+			"expired_entries":   expired,
+			"message":           phaseMessage(event, phase, minutesRemaining),
+		},
+		Timestamp: now,
+	})
+	return true, nil
+}
+
+func (s *ActivityScheduler) expireWaitingEntries(eventID uint) int64 {
+	var positionIDs []uint
+	s.db.Model(&models.QueueEntry{}).Where("event_id = ? AND status IN ?", eventID, []string{"waiting", "called"}).
+		Distinct().Pluck("position_id", &positionIDs)
+
+	result := s.db.Model(&models.QueueEntry{}).
+		Where("event_id = ? AND status IN ?", eventID, []string{"waiting", "called"}).
+		Updates(map[string]interface{}{"status": "expired", "is_active": false, "open_key": nil})
+	if result.Error != nil {
+		log.Printf("Activity scheduler: failed to expire queue entries for event %d: %v", eventID, result.Error)
+		return 0
+	}
+
+	for _, positionID := range positionIDs {
+		s.cache.Refresh(eventID, positionID)
+	}
+	return result.RowsAffected
+}
+
+func phaseMessage(event *models.Event, phase string, minutesRemaining int) string {
+	switch phase {
+	case models.EventActive:
+		return fmt.Sprintf("%s has started", event.Name)
+	case models.EventClosing:
+		return fmt.Sprintf("%s ends in %d minutes, queues are now closed to new candidates", event.Name, minutesRemaining)
+	case models.EventFinalCall:
+		return fmt.Sprintf("%s ends in %d minutes", event.Name, minutesRemaining)
+	case models.EventCompleted:
+		return fmt.Sprintf("%s has ended", event.Name)
+	default:
+		return fmt.Sprintf("%s has not started yet", event.Name)
+	}
+}
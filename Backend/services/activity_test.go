@@ -0,0 +1,223 @@
+package services
+
+import (
+	"interview-system/models"
+	"testing"
+	"time"
+)
+
+func TestPhaseAt(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+
+	tests := []struct {
+		name   string
+		status string
+		now    time.Time
+		want   string
+	}{
+		{"before start", models.EventPending, start.Add(-time.Minute), models.EventPending},
+		{"at start", models.EventPending, start, models.EventActive},
+		{"running", models.EventActive, start.Add(time.Hour), models.EventActive},
+		{"just before closing", models.EventActive, end.Add(-closingWindow - time.Second), models.EventActive},
+		{"closing", models.EventActive, end.Add(-closingWindow), models.EventClosing},
+		{"just before final call", models.EventClosing, end.Add(-finalCallWindow - time.Second), models.EventClosing},
+		{"final call", models.EventClosing, end.Add(-finalCallWindow), models.EventFinalCall},
+		{"last second", models.EventFinalCall, end.Add(-time.Second), models.EventFinalCall},
+		{"at end", models.EventFinalCall, end, models.EventCompleted},
+		{"long after end", models.EventActive, end.Add(time.Hour), models.EventCompleted},
+		{"ended early stays ended", models.EventCompleted, start.Add(time.Hour), models.EventCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &models.Event{Status: tt.status, StartTime: start, EndTime: end}
+			if got := PhaseAt(event, tt.now); got != tt.want {
+				t.Errorf("PhaseAt = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActivitySchedulerTick(t *testing.T) {
+	s, mr := newTestQueueCache(t)
+	event, positionID, candidates := seedQueue(t, s, 4)
+
+	start := time.Now().Truncate(time.Minute).Add(time.Hour)
+	end := start.Add(time.Hour)
+	if err := s.db.Model(event).Updates(map[string]interface{}{
+		"status":     models.EventPending,
+		"start_time": start,
+		"end_time":   end,
+	}).Error; err != nil {
+		t.Fatalf("update event: %v", err)
+	}
+
+	statuses := []string{"waiting", "waiting", "called", "interviewing"}
+	entryIDs := make([]uint, len(candidates))
+	for i, candidateID := range candidates {
+		entry := models.QueueEntry{
+			EventID:     event.ID,
+			CandidateID: candidateID,
+			PositionID:  positionID,
+			Round:       1,
+			Priority:    models.PriorityRegular,
+			JoinTime:    start,
+			Status:      statuses[i],
+			IsActive:    true,
+			OpenKey:     models.QueueOpenKey(event.ID, candidateID, positionID),
+		}
+		if err := s.db.Create(&entry).Error; err != nil {
+			t.Fatalf("create entry: %v", err)
+		}
+		entryIDs[i] = entry.ID
+	}
+	s.cache.Refresh(event.ID, positionID)
+
+	clock := newFakeClock(start.Add(-time.Minute))
+	scheduler := NewActivityScheduler(s.db, s.wsHub, s.cache, clock)
+
+	steps := []struct {
+		at   time.Time
+		want string
+	}{
+		{start.Add(-time.Minute), models.EventPending},
+		{start, models.EventActive},
+		{end.Add(-closingWindow), models.EventClosing},
+		{end.Add(-finalCallWindow), models.EventFinalCall},
+		{end.Add(-time.Second), models.EventFinalCall},
+		{end, models.EventCompleted},
+		{end.Add(time.Hour), models.EventCompleted},
+	}
+	for _, step := range steps {
+		clock.Set(step.at)
+		scheduler.Tick()
+
+		var got models.Event
+		if err := s.db.First(&got, event.ID).Error; err != nil {
+			t.Fatalf("load event: %v", err)
+		}
+		if got.Status != step.want {
+			t.Fatalf("at %s the event is %s, want %s", step.at.Sub(start), got.Status, step.want)
+		}
+
+		if step.want != models.EventCompleted {
+			var expired int64
+			s.db.Model(&models.QueueEntry{}).Where("status = ?", "expired").Count(&expired)
+			if expired > 0 {
+				t.Fatalf("%d entries expired while the event is %s", expired, step.want)
+			}
+		}
+	}
+
+	// Completion expired the waiting and called entries and nothing else
+	wantStatuses := []string{"expired", "expired", "expired", "interviewing"}
+	for i, id := range entryIDs {
+		var entry models.QueueEntry
+		if err := s.db.First(&entry, id).Error; err != nil {
+			t.Fatalf("load entry: %v", err)
+		}
+		if entry.Status != wantStatuses[i] {
+			t.Errorf("entry %d is %s, want %s", id, entry.Status, wantStatuses[i])
+		}
+		if entry.Status == "expired" && (entry.IsActive || entry.OpenKey != nil) {
+			t.Errorf("expired entry %d still active or holding its open key", id)
+		}
+	}
+	if mr.Exists(queueCacheKey(event.ID, positionID)) {
+		t.Error("cached queue not cleared after the event completed")
+	}
+}
+
+func TestActivitySchedulerSkipsToCompleted(t *testing.T) {
+	s, _ := newTestQueueCache(t)
+	event, positionID, candidates := seedQueue(t, s, 1)
+
+	entry := models.QueueEntry{
+		EventID:     event.ID,
+		CandidateID: candidates[0],
+		PositionID:  positionID,
+		Round:       1,
+		Priority:    models.PriorityRegular,
+		JoinTime:    event.StartTime,
+		Status:      "waiting",
+		OpenKey:     models.QueueOpenKey(event.ID, candidates[0], positionID),
+	}
+	if err := s.db.Create(&entry).Error; err != nil {
+		t.Fatalf("create entry: %v", err)
+	}
+
+	// The scheduler was down through closing and final call
+	clock := newFakeClock(event.EndTime.Add(time.Minute))
+	NewActivityScheduler(s.db, s.wsHub, s.cache, clock).Tick()
+
+	var got models.Event
+	s.db.First(&got, event.ID)
+	if got.Status != models.EventCompleted {
+		t.Errorf("event is %s, want %s", got.Status, models.EventCompleted)
+	}
+	s.db.First(&entry, entry.ID)
+	if entry.Status != "expired" {
+		t.Errorf("entry is %s, want expired", entry.Status)
+	}
+}
+
+func TestActivitySchedulerEnd(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+	}{
+		{"while active", models.EventActive},
+		{"during final call", models.EventFinalCall},
+		{"after it already ended", models.EventCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestQueueCache(t)
+			event, positionID, candidates := seedQueue(t, s, 2)
+			if err := s.db.Model(event).Update("status", tt.status).Error; err != nil {
+				t.Fatalf("update event: %v", err)
+			}
+
+			statuses := []string{"waiting", "called"}
+			for i, candidateID := range candidates {
+				entry := models.QueueEntry{
+					EventID:     event.ID,
+					CandidateID: candidateID,
+					PositionID:  positionID,
+					Round:       1,
+					Priority:    models.PriorityRegular,
+					JoinTime:    event.StartTime,
+					Status:      statuses[i],
+					OpenKey:     models.QueueOpenKey(event.ID, candidateID, positionID),
+				}
+				if err := s.db.Create(&entry).Error; err != nil {
+					t.Fatalf("create entry: %v", err)
+				}
+			}
+
+			// Admins end events ahead of time, well before the scheduler would
+			now := event.StartTime.Add(30 * time.Minute)
+			scheduler := NewActivityScheduler(s.db, s.wsHub, s.cache, newFakeClock(now))
+			if err := scheduler.End(&models.Event{ID: event.ID}); err != nil {
+				t.Fatalf("End: %v", err)
+			}
+
+			var got models.Event
+			s.db.First(&got, event.ID)
+			if got.Status != models.EventCompleted {
+				t.Errorf("event is %s, want %s", got.Status, models.EventCompleted)
+			}
+			if tt.status != models.EventCompleted && !got.EndTime.Equal(now) {
+				t.Errorf("event ends at %v, want %v", got.EndTime, now)
+			}
+
+			var open []models.QueueEntry
+			s.db.Where("event_id = ? AND (status <> ? OR open_key IS NOT NULL)", event.ID, "expired").Find(&open)
+			if len(open) > 0 {
+				t.Errorf("%d entries still open after the event ended", len(open))
+			}
+		})
+	}
+}
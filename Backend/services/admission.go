@@ -0,0 +1,126 @@
+package services
+
+import (
+	"interview-system/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// waitPenalty is how much score a regular entry loses per average interview
+// length of projected waiting.
+const waitPenalty = 0.2
+
+// Admission decides which of a candidate's queue entries in an event are
+// active. Entries that are already being served and high priority entries are
+// always active; the remaining slots up to the event's ActiveQueueLimit go to
+// the entries with the best comprehensive score:
+//
+//	score = priority level - (projected wait / average interview time) * 0.2
+//
+// so a position the candidate can be seen at soon beats one with a long queue.
+type Admission struct {
+	db    *gorm.DB
+	wsHub *WebSocketHub
+}
+
+func NewAdmission(db *gorm.DB, wsHub *WebSocketHub) *Admission {
+	return &Admission{db: db, wsHub: wsHub}
+}
+
+// AdmissionScore is the comprehensive score of an entry given its projected
+// wait in minutes.
+func AdmissionScore(entry *models.QueueEntry, waitMinutes float64, averageInterviewTime int) float64 {
+	baseline := float64(averageInterviewTime)
+	if baseline <= 0 {
+		baseline = 1
+	}
+	return float64(entry.Priority) - waitMinutes/baseline*waitPenalty
+}
+
+// Recompute re-evaluates the candidate's active entries in the event and
+// tells them about any entry that was promoted to active.
+func (a *Admission) Recompute(eventID, candidateID uint) error {
+	var event models.Event
+	if err := a.db.First(&event, eventID).Error; err != nil {
+		return err
+	}
+
+	var promoted []models.QueueEntry
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCandidate(tx, candidateID); err != nil {
+			return err
+		}
+
+		var entries []models.QueueEntry
+		if err := tx.Preload("Position").
+			Where("event_id = ? AND candidate_id = ? AND status NOT IN ?", eventID, candidateID, closedQueueStatuses).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		active := make(map[uint]bool, len(entries))
+		slots := event.ActiveQueueLimit
+		var ranked []*models.QueueEntry
+		for i := range entries {
+			entry := &entries[i]
+			if entry.Status != "waiting" || entry.Priority >= models.PriorityHigh {
+				active[entry.ID] = true
+				slots--
+				continue
+			}
+			ranked = append(ranked, entry)
+		}
+
+		score := func(entry *models.QueueEntry) float64 {
+			wait := 0.0
+			if entry.EstimatedTime != nil && entry.EstimatedTime.After(now) {
+				wait = entry.EstimatedTime.Sub(now).Minutes()
+			}
+			return AdmissionScore(entry, wait, event.AverageInterviewTime)
+		}
+		sort.SliceStable(ranked, func(i, j int) bool {
+			si, sj := score(ranked[i]), score(ranked[j])
+			if si != sj {
+				return si > sj
+			}
+			return ranked[i].JoinTime.Before(ranked[j].JoinTime)
+		})
+		for i, entry := range ranked {
+			active[entry.ID] = i < slots
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			if entry.IsActive == active[entry.ID] {
+				continue
+			}
+			if err := tx.Model(entry).UpdateColumn("is_active", active[entry.ID]).Error; err != nil {
+				return err
+			}
+			if active[entry.ID] {
+				promoted = append(promoted, *entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range promoted {
+		a.wsHub.BroadcastToUser(candidateID, Message{
+			Type: QueuePromoted,
+			Data: map[string]interface{}{
+				"position_id":   entry.PositionID,
+				"position_name": entry.Position.Name,
+				"message":       "Your queue for " + entry.Position.Name + " is now active",
+			},
+			Timestamp: time.Now(),
+		})
+	}
+
+	return nil
+}
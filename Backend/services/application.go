@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrApplicationNotFound = errors.New("application not found")
+	ErrInvalidTransition   = errors.New("application cannot move to that stage")
+	ErrReasonRequired      = errors.New("a reason is required to reject an application")
+)
+
+// ApplicationService runs the per-candidate, per-position hiring pipeline.
+// Applications open when a candidate first queues for a position and follow
+// the outcome of their interviews: interviewed once one ends, second round
+// when they pass into a next round. Company admins take them on from there.
+// Every change is recorded and the candidate is notified.
+type ApplicationService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+func NewApplicationService(db *gorm.DB, notifications *NotificationService) *ApplicationService {
+	return &ApplicationService{db: db, notifications: notifications}
+}
+
+// Ensure opens a queued application for the candidate unless one exists.
+func (s *ApplicationService) Ensure(candidateID, positionID uint) error {
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CandidatePosition{
+		CandidateID: candidateID,
+		PositionID:  positionID,
+		JoinedAt:    time.Now(),
+		Status:      models.ApplicationQueued,
+	}).Error
+}
+
+// InterviewEnded records the outcome of an interview on the candidate's
+// application within the transaction that ends it. Passing into a next round
+// moves the application to second round, any other outcome to interviewed.
+// Applications the company admin has already moved further only get the
+// outcome added to their history. passed is nil when the interview had no
+// outcome. The application is returned when its stage changed, so the caller
+// can notify the candidate once the transaction has committed.
+func (s *ApplicationService) InterviewEnded(tx *gorm.DB, candidateID, positionID uint, passed *bool, next *models.PositionRound) (*models.CandidatePosition, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CandidatePosition{
+		CandidateID: candidateID,
+		PositionID:  positionID,
+		JoinedAt:    time.Now(),
+		Status:      models.ApplicationQueued,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	var application models.CandidatePosition
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("candidate_id = ? AND position_id = ?", candidateID, positionID).
+		First(&application).Error; err != nil {
+		return nil, err
+	}
+
+	to, reason := models.ApplicationInterviewed, "Interview completed"
+	switch {
+	case passed == nil:
+	case *passed && next != nil:
+		to, reason = models.ApplicationSecondRound, fmt.Sprintf("Passed the interview, queued for %s", next.Name)
+	case *passed:
+		reason = "Passed the interview"
+	default:
+		reason = "Did not pass the interview"
+	}
+
+	if !canAdvanceAfterInterview(application.Status, to) {
+		return nil, tx.Create(&models.ApplicationTransition{
+			ApplicationID: application.ID,
+			FromStatus:    application.Status,
+			ToStatus:      application.Status,
+			Reason:        reason,
+			ActorRole:     ActorSystem,
+		}).Error
+	}
+	if err := applyTransition(tx, &application, to, reason, nil, ActorSystem); err != nil {
+		return nil, err
+	}
+	return &application, nil
+}
+
+// canAdvanceAfterInterview reports whether an interview outcome may move the
+// application on by itself. Interviewed only follows queued; second round may
+// also skip the stages a company admin would otherwise set.
+func canAdvanceAfterInterview(from, to string) bool {
+	switch to {
+	case models.ApplicationInterviewed:
+		return from == models.ApplicationQueued
+	case models.ApplicationSecondRound:
+		return from == models.ApplicationQueued || from == models.ApplicationInterviewed ||
+			from == models.ApplicationShortlisted
+	}
+	return false
+}
+
+// Advance moves one of the company's applications to the given stage.
+// Rejections need a reason.
+func (s *ApplicationService) Advance(companyID, applicationID uint, to, reason string, actorID uint) (*models.CandidatePosition, error) {
+	reason = strings.TrimSpace(reason)
+	if to == models.ApplicationRejected && reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	var count int64
+	if err := s.db.Model(&models.CandidatePosition{}).
+		Joins("JOIN positions ON positions.id = candidate_positions.position_id").
+		Where("candidate_positions.id = ? AND positions.company_id = ?", applicationID, companyID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrApplicationNotFound
+	}
+
+	return s.transition(applicationID, to, reason, &actorID, string(models.RoleCompanyAdmin))
+}
+
+// ForCompany lists the applications for the company's positions, optionally
+// narrowed to one position and one stage.
+func (s *ApplicationService) ForCompany(companyID, positionID uint, status string) ([]models.CandidatePosition, error) {
+	query := s.db.Preload("Candidate").Preload("Position").
+		Preload("Transitions", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Joins("JOIN positions ON positions.id = candidate_positions.position_id").
+		Where("positions.company_id = ?", companyID)
+	if positionID != 0 {
+		query = query.Where("candidate_positions.position_id = ?", positionID)
+	}
+	if status != "" {
+		query = query.Where("candidate_positions.status = ?", status)
+	}
+
+	var applications []models.CandidatePosition
+	if err := query.Order("candidate_positions.updated_at DESC").Find(&applications).Error; err != nil {
+		return nil, err
+	}
+	return applications, nil
+}
+
+// ForCandidate lists the candidate's own applications with their history.
+func (s *ApplicationService) ForCandidate(candidateID uint) ([]models.CandidatePosition, error) {
+	var applications []models.CandidatePosition
+	if err := s.db.Preload("Position.Company").
+		Preload("Transitions", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("candidate_id = ?", candidateID).
+		Order("updated_at DESC").Find(&applications).Error; err != nil {
+		return nil, err
+	}
+	return applications, nil
+}
+
+// transition moves the application under a row lock, records the change and
+// notifies the candidate once it is committed.
+func (s *ApplicationService) transition(applicationID uint, to, reason string, actorID *uint, actorRole string) (*models.CandidatePosition, error) {
+	var application models.CandidatePosition
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&application, applicationID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrApplicationNotFound
+			}
+			return err
+		}
+
+		if !models.CanTransitionApplication(application.Status, to) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, application.Status, to)
+		}
+		return applyTransition(tx, &application, to, reason, actorID, actorRole)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Preload("Position.Company").
+		Preload("Transitions", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&application, application.ID).Error; err != nil {
+		return nil, err
+	}
+
+	s.Notify(&application)
+	return &application, nil
+}
+
+// applyTransition moves the locked application to the stage and records the
+// change.
+func applyTransition(tx *gorm.DB, application *models.CandidatePosition, to, reason string, actorID *uint, actorRole string) error {
+	from := application.Status
+	application.Status = to
+	application.StatusReason = reason
+	if err := tx.Model(application).Updates(map[string]interface{}{
+		"status":        to,
+		"status_reason": reason,
+	}).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.ApplicationTransition{
+		ApplicationID: application.ID,
+		FromStatus:    from,
+		ToStatus:      to,
+		Reason:        reason,
+		ActorID:       actorID,
+		ActorRole:     actorRole,
+	}).Error
+}
+
+// Notify tells the candidate which stage their application is in now. It is
+// a no-op for a nil application, so the result of InterviewEnded can be
+// passed straight in.
+func (s *ApplicationService) Notify(application *models.CandidatePosition) {
+	if application == nil {
+		return
+	}
+
+	positionName := application.Position.Name
+	if positionName == "" {
+		var position models.Position
+		if err := s.db.Select("id", "name").First(&position, application.PositionID).Error; err == nil {
+			positionName = position.Name
+		}
+	}
+
+	message := fmt.Sprintf("Your application for %s is now %s", positionName, stageLabel(application.Status))
+	if application.StatusReason != "" {
+		message += ": " + application.StatusReason
+	}
+	if _, err := s.notifications.Notify(application.CandidateID, ApplicationStatus, "Application update", message, map[string]interface{}{
+		"application_id": application.ID,
+		"position_id":    application.PositionID,
+		"status":         application.Status,
+		"reason":         application.StatusReason,
+	}); err != nil {
+		log.Printf("Failed to notify candidate %d of application %d: %v", application.CandidateID, application.ID, err)
+	}
+}
+
+func stageLabel(status string) string {
+	return strings.ReplaceAll(status, "_", " ")
+}
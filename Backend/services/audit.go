@@ -0,0 +1,143 @@
+package services
+
+import (
+	"interview-system/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Types of change recorded in the queue audit trail.
+const (
+	OptimizationJumpAhead = "jump_ahead"
+	OptimizationReorder   = "queue_optimization"
+	OptimizationDelay     = "delay"
+	OptimizationConflict  = "conflict_resolution"
+	OptimizationNoShow    = "no_show"
+)
+
+// Results of audited changes. Jump aheads are suggested first and then
+// confirmed or expired; everything else is applied directly.
+const (
+	OptimizationSuggested = "suggested"
+	OptimizationConfirmed = "confirmed"
+	OptimizationExpired   = "expired"
+	OptimizationApplied   = "applied"
+)
+
+// Who triggered an audited change.
+const (
+	ActorCandidate = "candidate"
+	ActorSystem    = "system"
+)
+
+// etaMinutesSaved is how many minutes earlier the interview now starts.
+func etaMinutesSaved(before, after *time.Time) int {
+	if before == nil || after == nil {
+		return 0
+	}
+	return int(before.Sub(*after).Round(time.Minute).Minutes())
+}
+
+// auditCandidateChanges records one applied change per entry. The entries
+// hold their state from before the change; the new ETA is read back from the
+// schedule projection, so this runs once the event has been recomputed.
+func auditCandidateChanges(db *gorm.DB, optType string, candidateID uint, before []models.QueueEntry, details map[uint]string) {
+	for _, entry := range before {
+		var after models.QueueEntry
+		if err := db.Select("id", "estimated_time").First(&after, entry.ID).Error; err != nil {
+			log.Printf("Queue audit: failed to reload entry %d: %v", entry.ID, err)
+			continue
+		}
+
+		actorID := candidateID
+		record := models.QueueOptimization{
+			EventID:     entry.EventID,
+			CandidateID: entry.CandidateID,
+			PositionID:  entry.PositionID,
+			Type:        optType,
+			Details:     details[entry.ID],
+			Result:      OptimizationApplied,
+			BeforeETA:   entry.EstimatedTime,
+			AfterETA:    after.EstimatedTime,
+			TimeSaved:   etaMinutesSaved(entry.EstimatedTime, after.EstimatedTime),
+			ActorID:     &actorID,
+			ActorRole:   ActorCandidate,
+		}
+		if err := db.Omit(clause.Associations).Create(&record).Error; err != nil {
+			log.Printf("Queue audit: failed to record %s for entry %d: %v", optType, entry.ID, err)
+		}
+	}
+}
+
+// History returns the candidate's queue audit trail, newest first.
+func (s *QueueService) History(candidateID uint) ([]models.QueueOptimization, error) {
+	var records []models.QueueOptimization
+	err := s.db.Preload("Position").
+		Where("candidate_id = ?", candidateID).
+		Order("created_at DESC, id DESC").
+		Find(&records).Error
+	return records, err
+}
+
+// recordAfterETA fills in the ETA an audited entry ended up with once the
+// schedule has been recomputed.
+func recordAfterETA(db *gorm.DB, recordID uint, entryID uint) {
+	var entry models.QueueEntry
+	if err := db.Select("id", "estimated_time").First(&entry, entryID).Error; err != nil {
+		log.Printf("Queue audit: failed to reload entry %d: %v", entryID, err)
+		return
+	}
+	if err := db.Model(&models.QueueOptimization{}).Where("id = ?", recordID).
+		UpdateColumn("after_eta", entry.EstimatedTime).Error; err != nil {
+		log.Printf("Queue audit: failed to update record %d: %v", recordID, err)
+	}
+}
+
+// OptimizationFilter narrows the audit trail for control admins. Zero values
+// don't filter.
+type OptimizationFilter struct {
+	EventID     uint
+	CandidateID uint
+	PositionID  uint
+	Type        string
+	Result      string
+	From        *time.Time
+	To          *time.Time
+	Limit       int
+}
+
+// ListOptimizations returns audit records matching the filter, newest first.
+func (s *QueueService) ListOptimizations(filter OptimizationFilter) ([]models.QueueOptimization, error) {
+	query := s.db.Preload("Position").Order("created_at DESC, id DESC")
+	if filter.EventID > 0 {
+		query = query.Where("event_id = ?", filter.EventID)
+	}
+	if filter.CandidateID > 0 {
+		query = query.Where("candidate_id = ?", filter.CandidateID)
+	}
+	if filter.PositionID > 0 {
+		query = query.Where("position_id = ?", filter.PositionID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Result != "" {
+		query = query.Where("result = ?", filter.Result)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var records []models.QueueOptimization
+	err := query.Find(&records).Error
+	return records, err
+}
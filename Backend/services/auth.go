@@ -1,20 +1,45 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"interview-system/config"
 	"interview-system/models"
+	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+)
+
+// AuthService issues short-lived access tokens and rotating refresh tokens.
+// Every login starts a session; its ID is the access token's jti, so logging
+// out revokes the session's tokens at once, and revoking a user rejects every
+// token issued to them before that moment.
 type AuthService struct {
-	db     *gorm.DB
-	config *config.JWTConfig
+	db          *gorm.DB
+	config      *config.JWTConfig
+	revocations *RevocationList
+	wsHub       *WebSocketHub
+	guard       *LoginGuard
+}
+
+func init() {
+	// Issue times carry milliseconds, so a token issued right after the user's
+	// tokens were revoked can be told apart from those revoked
+	jwt.TimePrecision = time.Millisecond
 }
 
 type Claims struct {
@@ -26,39 +51,190 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func NewAuthService(db *gorm.DB, cfg *config.JWTConfig) *AuthService {
+// TokenPair is what a login or refresh hands out. ExpiresIn is the access
+// token's lifetime in seconds.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+func NewAuthService(db *gorm.DB, cfg *config.JWTConfig, revocations *RevocationList, wsHub *WebSocketHub, guard *LoginGuard) *AuthService {
 	return &AuthService{
-		db:     db,
-		config: cfg,
+		db:          db,
+		config:      cfg,
+		revocations: revocations,
+		wsHub:       wsHub,
+		guard:       guard,
 	}
 }
 
-func (s *AuthService) Login(account, password string) (string, *models.User, error) {
+// Login checks the password unless the account or IP is locked out, and
+// records the attempt either way. Failures return ErrInvalidCredentials
+// whatever the reason, and a lockout a *LockoutError.
+func (s *AuthService) Login(account, password, ip, userAgent string) (*TokenPair, *models.User, error) {
+	if err := s.guard.Check(account, ip); err != nil {
+		if errors.Is(err, ErrLoginLocked) {
+			s.guard.Refused(account, ip, userAgent)
+		}
+		return nil, nil, err
+	}
+
 	var user models.User
-	if err := s.db.Preload("Company").Where("account = ? AND is_active = ?", account, true).First(&user).Error; err != nil {
+	if err := s.db.Preload("Company").Where("account = ?", account).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", nil, errors.New("invalid account or password")
+			s.guard.Failed(nil, account, ip, userAgent)
+			return nil, nil, ErrInvalidCredentials
 		}
-		return "", nil, err
+		return nil, nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", nil, errors.New("invalid account or password")
+	if !user.IsActive || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		s.guard.Failed(&user.ID, account, ip, userAgent)
+		return nil, nil, ErrInvalidCredentials
 	}
+	s.guard.Succeeded(user.ID, account, ip, userAgent)
 
 	now := time.Now()
 	user.LastLogin = &now
 	s.db.Save(&user)
 
-	token, err := s.GenerateToken(&user)
+	tokens, err := s.issueTokens(s.db, &user, uuid.New().String(), ip, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tokens, &user, nil
+}
+
+// Refresh swaps a refresh token for a new token pair in the same session. A
+// token that was already used revokes the session, as only a stolen copy
+// would be presented twice.
+func (s *AuthService) Refresh(refreshToken, ip, userAgent string) (*TokenPair, error) {
+	var tokens *TokenPair
+	var reused *models.RefreshToken
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashToken(refreshToken)).First(&stored).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		now := time.Now()
+		if stored.RevokedAt != nil || now.After(stored.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+		if stored.UsedAt != nil {
+			reused = &stored
+			return ErrInvalidRefreshToken
+		}
+
+		var user models.User
+		if err := tx.Where("id = ? AND is_active = ?", stored.UserID, true).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		if err := tx.Model(&stored).Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		var err error
+		tokens, err = s.issueTokens(tx, &user, stored.SessionID, ip, userAgent)
+		return err
+	})
+	if reused != nil {
+		log.Printf("Refresh token reuse for user %d, revoking session %s", reused.UserID, reused.SessionID)
+		if err := s.RevokeSession(reused.UserID, reused.SessionID); err != nil {
+			log.Printf("Failed to revoke session %s: %v", reused.SessionID, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeSession logs one session out: its refresh tokens stop working, its
+// access tokens are rejected and its WebSocket connections are closed.
+func (s *AuthService) RevokeSession(userID uint, sessionID string) error {
+	if err := s.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND session_id = ? AND revoked_at IS NULL", userID, sessionID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	s.revocations.RevokeSession(sessionID, s.config.Expiration)
+	s.wsHub.DisconnectSession(sessionID)
+	return nil
+}
+
+// RevokeUser logs the user out of every session, e.g. when an admin forces
+// it or the account is deactivated.
+func (s *AuthService) RevokeUser(userID uint) error {
+	if err := s.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	s.revocations.RevokeUser(userID, s.config.Expiration)
+	s.wsHub.DisconnectUser(userID)
+	return nil
+}
+
+// issueTokens signs an access token for the session and stores a new refresh
+// token for it.
+func (s *AuthService) issueTokens(tx *gorm.DB, user *models.User, sessionID, ip, userAgent string) (*TokenPair, error) {
+	accessToken, err := s.GenerateToken(user, sessionID)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
-	return token, &user, nil
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		SessionID: sessionID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.config.RefreshExpiration),
+		IP:        ip,
+		UserAgent: userAgent,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.config.Expiration.Seconds()),
+	}, nil
+}
+
+// newOpaqueToken returns a random URL-safe token for refresh tokens and
+// invitations.
+func newOpaqueToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashToken is how opaque tokens are stored, so a database leak doesn't hand
+// out live sessions or invitations.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
-func (s *AuthService) GenerateToken(user *models.User) (string, error) {
+func (s *AuthService) GenerateToken(user *models.User, sessionID string) (string, error) {
 	expirationTime := time.Now().Add(s.config.Expiration)
 
 	claims := &Claims{
@@ -68,6 +244,7 @@ func (s *AuthService) GenerateToken(user *models.User) (string, error) {
 		Role:      user.Role,
 		CompanyID: user.CompanyID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "interview-system",
@@ -100,6 +277,14 @@ func (s *AuthService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, errors.New("invalid token")
 	}
 
+	if claims.ID != "" && s.revocations.SessionRevoked(claims.ID) {
+		return nil, ErrTokenRevoked
+	}
+	if revokedAt, ok := s.revocations.UserRevokedAt(claims.UserID); ok &&
+		(claims.IssuedAt == nil || !claims.IssuedAt.Time.After(revokedAt)) {
+		return nil, ErrTokenRevoked
+	}
+
 	return claims, nil
 }
 
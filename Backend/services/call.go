@@ -0,0 +1,414 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNoAssignedPosition = errors.New("you are not assigned to a position")
+	ErrInterviewerBusy    = errors.New("you already have an interview in progress")
+	ErrCallPending        = errors.New("you are still waiting for the candidate you called")
+	ErrNoCandidateToCall  = errors.New("no candidate is available to call")
+	ErrNotCalled          = errors.New("no open call to check in to")
+)
+
+// noShowDemotions is how many missed calls a candidate gets away with being
+// moved to the back of the queue; after that a missed call removes them.
+const noShowDemotions = 1
+
+// CallService lets interviewers call the next candidate from their position
+// queue instead of picking one by hand. The called entry leaves the waiting
+// list as "called"; the candidate has the event's CallGracePeriod to check in
+// (or be marked present), otherwise they are a no-show and the next
+// candidate is called.
+type CallService struct {
+	db        *gorm.DB
+	wsHub     *WebSocketHub
+	events    *EventService
+	cache     *QueueCache
+	schedule  *ScheduleEngine
+	admission *Admission
+	clock     Clock
+}
+
+func NewCallService(db *gorm.DB, wsHub *WebSocketHub, events *EventService, cache *QueueCache, schedule *ScheduleEngine, admission *Admission, clock Clock) *CallService {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &CallService{
+		db:        db,
+		wsHub:     wsHub,
+		events:    events,
+		cache:     cache,
+		schedule:  schedule,
+		admission: admission,
+		clock:     clock,
+	}
+}
+
+// CallNext calls the next candidate for the interviewer. Each round queue of
+// their positions that they interview for, or only those of positionID when
+// it is non-zero, offers its head; candidates who are being interviewed or called elsewhere, and
+// candidates whose delay hasn't run out yet, are skipped but keep their place.
+// Of those heads the one the schedule expects to be seen first is called.
+// Only the queues of the running event are called from.
+func (s *CallService) CallNext(interviewerID uint, positionID uint) (*models.QueueEntry, error) {
+	event, err := s.events.Running(s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	positionIDs, err := InterviewerPositions(s.db, interviewerID, positionID)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := InterviewerRoundPool(s.db, interviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry models.QueueEntry
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Serialise calls by the same interviewer
+		var interviewer models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&interviewer, interviewerID).Error; err != nil {
+			return err
+		}
+
+		var inProgress int64
+		tx.Model(&models.Interview{}).Where("interviewer_id = ? AND status = ?", interviewerID, models.InterviewInProgress).
+			Count(&inProgress)
+		if inProgress > 0 {
+			return ErrInterviewerBusy
+		}
+
+		var pending int64
+		tx.Model(&models.QueueEntry{}).Where("called_by = ? AND status = ?", interviewerID, "called").Count(&pending)
+		if pending > 0 {
+			return ErrCallPending
+		}
+
+		var waiting []models.QueueEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("event_id = ? AND position_id IN ? AND status = ?", event.ID, positionIDs, "waiting").
+			Order("position_id ASC, " + models.QueueOrder).Find(&waiting).Error; err != nil {
+			return err
+		}
+
+		candidateIDs := make([]uint, len(waiting))
+		for i := range waiting {
+			candidateIDs[i] = waiting[i].CandidateID
+		}
+		busy, err := BusyCandidates(tx, candidateIDs)
+		if err != nil {
+			return err
+		}
+
+		now := s.clock.Now()
+		var next *models.QueueEntry
+		headFound := make(map[roundKey]bool)
+		for i := range waiting {
+			head := &waiting[i]
+			key := roundKey{head.PositionID, entryRound(head.Round)}
+			if !pool.Covers(head.PositionID, head.Round) || headFound[key] ||
+				head.JoinTime.After(now) || busy[head.CandidateID] {
+				continue
+			}
+			headFound[key] = true
+			if next == nil || callsBefore(head, next) {
+				next = head
+			}
+		}
+		if next == nil {
+			return ErrNoCandidateToCall
+		}
+
+		entry = *next
+		entry.Status = "called"
+		entry.CalledAt = &now
+		entry.CalledBy = &interviewerID
+		if err := tx.Model(&entry).Updates(map[string]interface{}{
+			"status":    "called",
+			"called_at": now,
+			"called_by": interviewerID,
+		}).Error; err != nil {
+			return err
+		}
+		return reorderQueue(tx, entry.EventID, entry.PositionID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	grace := time.Duration(event.CallGracePeriod) * time.Minute
+
+	s.cache.Remove(entry.EventID, entry.PositionID, entry.CandidateID)
+	s.reschedule(entry.EventID, entry.CandidateID)
+	s.db.Preload("Candidate").Preload("Position").First(&entry, entry.ID)
+	s.notifyCalled(&entry, interviewerID, grace)
+	s.scheduleExpiry(entry.ID, grace)
+
+	return &entry, nil
+}
+
+// ResumePending re-arms the expiry timers of open calls, e.g. after a
+// restart. Overdue calls lapse immediately.
+func (s *CallService) ResumePending() {
+	var entries []models.QueueEntry
+	s.db.Where("status = ?", "called").Find(&entries)
+
+	now := s.clock.Now()
+	for _, entry := range entries {
+		wait := time.Duration(0)
+		if deadline, ok := s.callDeadline(&entry); ok && deadline.After(now) {
+			wait = deadline.Sub(now)
+		}
+		s.scheduleExpiry(entry.ID, wait)
+	}
+}
+
+func (s *CallService) scheduleExpiry(entryID uint, wait time.Duration) {
+	time.AfterFunc(wait, func() {
+		if err := s.ExpireCall(entryID); err != nil {
+			log.Printf("Queue entry %d: call expiry failed: %v", entryID, err)
+		}
+	})
+}
+
+// callDeadline is when the entry's call lapses.
+func (s *CallService) callDeadline(entry *models.QueueEntry) (time.Time, bool) {
+	if entry.CalledAt == nil {
+		return time.Time{}, false
+	}
+	event, err := s.events.Resolve(entry.EventID)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return entry.CalledAt.Add(time.Duration(event.CallGracePeriod) * time.Minute), true
+}
+
+// ExpireCall handles a call that ran out of time. A candidate who checked in
+// is left for the interviewer to start; anyone else is a no-show. Their
+// no-show count goes up and the entry is demoted to the back of the queue, or
+// removed if they have already missed a call earlier in the event. The
+// interviewer then gets the next candidate.
+func (s *CallService) ExpireCall(entryID uint) error {
+	var entry models.QueueEntry
+	var noShows int
+	handled := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, entryID).Error; err != nil {
+			return err
+		}
+		if entry.Status != "called" || entry.CheckedInAt != nil {
+			return nil
+		}
+		if deadline, ok := s.callDeadline(&entry); ok && s.clock.Now().Before(deadline) {
+			return nil
+		}
+
+		if err := lockCandidate(tx, entry.CandidateID); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", entry.CandidateID).
+			UpdateColumn("no_show_count", gorm.Expr("no_show_count + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entry).UpdateColumn("no_show_count", gorm.Expr("no_show_count + 1")).Error; err != nil {
+			return err
+		}
+
+		// Misses in earlier events don't count against the candidate here
+		if err := tx.Model(&models.QueueEntry{}).
+			Where("event_id = ? AND candidate_id = ?", entry.EventID, entry.CandidateID).
+			Select("COALESCE(SUM(no_show_count), 0)").Scan(&noShows).Error; err != nil {
+			return err
+		}
+
+		if noShows > noShowDemotions {
+			updates := models.CloseQueueEntry("no_show")
+			updates["is_active"] = false
+			if err := tx.Model(&entry).Updates(updates).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(&entry).Updates(map[string]interface{}{
+			"status":            "waiting",
+			"priority":          models.PriorityRegular,
+			"is_high_priority":  false,
+			"time_saved":        0,
+			"priority_set_time": nil,
+			"join_time":         s.clock.Now(),
+			"called_at":         nil,
+			"called_by":         nil,
+		}).Error; err != nil {
+			return err
+		}
+
+		handled = true
+		return reorderQueue(tx, entry.EventID, entry.PositionID)
+	})
+	if err != nil || !handled {
+		return err
+	}
+
+	removed := noShows > noShowDemotions
+	interviewerID := entry.CalledBy
+	s.recordNoShow(&entry, removed)
+
+	var demoted models.QueueEntry
+	if !removed && s.db.First(&demoted, entry.ID).Error == nil {
+		s.cache.Add(&demoted)
+	}
+	s.reschedule(entry.EventID, entry.CandidateID)
+	s.db.Preload("Position").First(&entry, entry.ID)
+
+	text := fmt.Sprintf("You didn't check in for %s in time and were moved to the back of the queue", entry.Position.Name)
+	if removed {
+		text = fmt.Sprintf("You didn't check in for %s in time and were removed from its queue", entry.Position.Name)
+	}
+	message := Message{
+		Type: CandidateNoShow,
+		Data: map[string]interface{}{
+			"queue_entry_id": entry.ID,
+			"candidate_id":   entry.CandidateID,
+			"position_id":    entry.PositionID,
+			"position_name":  entry.Position.Name,
+			"no_show_count":  noShows,
+			"removed":        removed,
+			"message":        text,
+		},
+		Timestamp: s.clock.Now(),
+	}
+	s.wsHub.BroadcastToUser(entry.CandidateID, message)
+	if interviewerID == nil {
+		return nil
+	}
+	s.wsHub.BroadcastToUser(*interviewerID, message)
+
+	if _, err := s.CallNext(*interviewerID, 0); err != nil &&
+		!errors.Is(err, ErrNoCandidateToCall) && !errors.Is(err, ErrInterviewerBusy) && !errors.Is(err, ErrCallPending) {
+		return err
+	}
+	return nil
+}
+
+// recordNoShow adds the demotion or removal to the queue audit trail.
+func (s *CallService) recordNoShow(entry *models.QueueEntry, removed bool) {
+	details := "Missed the call and moved to the back of the queue"
+	if removed {
+		details = "Missed the call again and was removed from the queue"
+	}
+	if err := s.db.Omit(clause.Associations).Create(&models.QueueOptimization{
+		EventID:     entry.EventID,
+		CandidateID: entry.CandidateID,
+		PositionID:  entry.PositionID,
+		Type:        OptimizationNoShow,
+		Details:     details,
+		Result:      OptimizationApplied,
+		BeforeETA:   entry.CalledAt,
+		ActorRole:   ActorSystem,
+	}).Error; err != nil {
+		log.Printf("Queue audit: failed to record no-show for entry %d: %v", entry.ID, err)
+	}
+}
+
+// CheckIn records that the called candidate has turned up for the position.
+func (s *CallService) CheckIn(candidateID, positionID uint) (*models.QueueEntry, error) {
+	return s.checkIn("candidate_id = ? AND position_id = ?", candidateID, positionID)
+}
+
+// MarkPresent lets the interviewer check in the candidate they called, for
+// candidates who turn up without using the app.
+func (s *CallService) MarkPresent(interviewerID uint) (*models.QueueEntry, error) {
+	return s.checkIn("called_by = ?", interviewerID)
+}
+
+func (s *CallService) checkIn(query string, args ...interface{}) (*models.QueueEntry, error) {
+	var entry models.QueueEntry
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(query, args...).Where("status = ?", "called").
+			First(&entry).Error; err != nil {
+			return ErrNotCalled
+		}
+		if entry.CheckedInAt != nil {
+			return nil
+		}
+
+		now := s.clock.Now()
+		entry.CheckedInAt = &now
+		return tx.Model(&entry).UpdateColumn("checked_in_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.db.Preload("Candidate").Preload("Position").First(&entry, entry.ID)
+
+	message := Message{
+		Type: CandidateCheckedIn,
+		Data: map[string]interface{}{
+			"queue_entry_id": entry.ID,
+			"candidate_id":   entry.CandidateID,
+			"candidate_name": entry.Candidate.Name,
+			"position_id":    entry.PositionID,
+			"position_name":  entry.Position.Name,
+			"checked_in_at":  entry.CheckedInAt,
+		},
+		Timestamp: s.clock.Now(),
+	}
+	s.wsHub.BroadcastToUser(entry.CandidateID, message)
+	if entry.CalledBy != nil {
+		s.wsHub.BroadcastToUser(*entry.CalledBy, message)
+	}
+
+	return &entry, nil
+}
+
+// callsBefore reports whether queue head a should be called before head b of
+// another position: whoever the schedule expects to see first, falling back
+// to queue order for entries that haven't been projected yet.
+func callsBefore(a, b *models.QueueEntry) bool {
+	if a.EstimatedTime != nil && b.EstimatedTime != nil && !a.EstimatedTime.Equal(*b.EstimatedTime) {
+		return a.EstimatedTime.Before(*b.EstimatedTime)
+	}
+	if (a.EstimatedTime == nil) != (b.EstimatedTime == nil) {
+		return a.EstimatedTime != nil
+	}
+	return models.QueueEntryLess(a, b)
+}
+
+func (s *CallService) notifyCalled(entry *models.QueueEntry, interviewerID uint, grace time.Duration) {
+	now := s.clock.Now()
+	data := map[string]interface{}{
+		"queue_entry_id": entry.ID,
+		"candidate_id":   entry.CandidateID,
+		"candidate_name": entry.Candidate.Name,
+		"position_id":    entry.PositionID,
+		"position_name":  entry.Position.Name,
+		"interviewer_id": interviewerID,
+		"expires_at":     now.Add(grace),
+		"grace_seconds":  int(grace.Seconds()),
+		"message":        fmt.Sprintf("You're up for %s, please go to the interviewer now", entry.Position.Name),
+	}
+	s.wsHub.BroadcastToUser(entry.CandidateID, Message{Type: CandidateCalled, Data: data, Timestamp: now})
+	s.wsHub.BroadcastToUser(interviewerID, Message{Type: CandidateCalled, Data: data, Timestamp: now})
+	s.wsHub.BroadcastToAll(Message{
+		Type:      QueueUpdate,
+		Data:      map[string]interface{}{"position_id": entry.PositionID},
+		Timestamp: now,
+	})
+}
+
+func (s *CallService) reschedule(eventID, candidateID uint) {
+	s.schedule.Request(eventID, func() {
+		if err := s.admission.Recompute(eventID, candidateID); err != nil {
+			log.Printf("Failed to recompute active queues for candidate %d: %v", candidateID, err)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"errors"
+	"interview-system/models"
+	"testing"
+	"time"
+)
+
+func TestCallNextOnlyCallsFromTheRunningEvent(t *testing.T) {
+	s := newTestQueueService(t)
+	event, positionID, users := seedQueue(t, s, 3)
+	interviewerID, current, leftover := users[0], users[1], users[2]
+	if err := s.db.Create(&models.PositionInterviewer{PositionID: positionID, InterviewerID: interviewerID}).Error; err != nil {
+		t.Fatalf("assign interviewer: %v", err)
+	}
+
+	// An entry an earlier event never expired, which would otherwise be first
+	earlier := models.Event{Name: "Earlier", Date: event.Date, StartTime: event.StartTime.Add(-48 * time.Hour),
+		EndTime: event.StartTime.Add(-44 * time.Hour), Status: models.EventCompleted, AverageInterviewTime: 15}
+	if err := s.db.Create(&earlier).Error; err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	addWaiting(t, s, models.QueueEntry{EventID: earlier.ID, CandidateID: leftover, PositionID: positionID,
+		Priority: models.PriorityRegular, JoinTime: earlier.StartTime})
+	addWaiting(t, s, models.QueueEntry{EventID: event.ID, CandidateID: current, PositionID: positionID,
+		Priority: models.PriorityRegular, JoinTime: event.StartTime})
+
+	clock := newFakeClock(time.Now())
+	calls := NewCallService(s.db, s.wsHub, s.events, s.cache, s.schedule, s.admission, clock)
+
+	entry, err := calls.CallNext(interviewerID, 0)
+	if err != nil {
+		t.Fatalf("CallNext: %v", err)
+	}
+	if entry.EventID != event.ID || entry.CandidateID != current {
+		t.Errorf("called candidate %d of event %d, want candidate %d of event %d",
+			entry.CandidateID, entry.EventID, current, event.ID)
+	}
+
+	clock.Set(event.EndTime.Add(time.Minute))
+	if _, err := calls.CallNext(interviewerID, 0); !errors.Is(err, ErrEventNotRunning) {
+		t.Errorf("CallNext after the event = %v, want %v", err, ErrEventNotRunning)
+	}
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPositionNotFound   = errors.New("position not found")
+	ErrScorecardNotFound  = errors.New("no scorecard template for this position")
+	ErrInvalidScorecard   = errors.New("invalid scorecard template")
+	ErrInvalidEvaluation  = errors.New("invalid evaluation")
+	ErrEvaluationRequired = errors.New("this position requires a scorecard evaluation")
+	ErrAlreadyEvaluated   = errors.New("interview has already been evaluated")
+	ErrInterviewNotFound  = errors.New("interview not found")
+	ErrNotYourInterview   = errors.New("interview belongs to another interviewer")
+)
+
+// defaultScorecardScale is used when a template doesn't set its own scale.
+const defaultScorecardScale = 5
+
+type ScorecardInput struct {
+	Name     string           `json:"name"`
+	Scale    int              `json:"scale"`
+	Criteria []CriterionInput `json:"criteria"`
+}
+
+type CriterionInput struct {
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	Weight          float64 `json:"weight"`
+	CommentRequired bool    `json:"comment_required"`
+}
+
+// EvaluationInput is what an interviewer submits for an interview. Scores are
+// required, one per criterion, when the position has a scorecard template.
+type EvaluationInput struct {
+	Recommendation string       `json:"recommendation"`
+	Comments       string       `json:"comments"`
+	Scores         []ScoreInput `json:"scores"`
+}
+
+type ScoreInput struct {
+	CriterionID uint   `json:"criterion_id"`
+	Score       int    `json:"score"`
+	Comment     string `json:"comment"`
+}
+
+// CandidateEvaluationSummary lines up a candidate's evaluations for one
+// position so company admins can compare candidates side by side.
+type CandidateEvaluationSummary struct {
+	CandidateID   uint    `json:"candidate_id"`
+	CandidateName string  `json:"candidate_name"`
+	Evaluations   int     `json:"evaluations"`
+	AverageScore  float64 `json:"average_score"`
+	Hire          int     `json:"hire"`
+	NoHire        int     `json:"no_hire"`
+	// CriterionAverages is keyed by criterion name
+	CriterionAverages map[string]float64 `json:"criterion_averages"`
+}
+
+// EvaluationService manages scorecard templates and interview evaluations.
+// Both belong to the company that owns the position and are never shown to
+// another company.
+type EvaluationService struct {
+	db *gorm.DB
+}
+
+func NewEvaluationService(db *gorm.DB) *EvaluationService {
+	return &EvaluationService{db: db}
+}
+
+// Template returns the position's scorecard template as seen by its company.
+func (s *EvaluationService) Template(companyID, positionID uint) (*models.ScorecardTemplate, error) {
+	if _, err := s.companyPosition(companyID, positionID); err != nil {
+		return nil, err
+	}
+	return loadTemplate(s.db, positionID)
+}
+
+// InterviewerTemplate returns the scorecard an interviewer fills in for one of
+// their positions.
+func (s *EvaluationService) InterviewerTemplate(interviewerID, positionID uint) (*models.ScorecardTemplate, error) {
+	if _, err := InterviewerPositions(s.db, interviewerID, positionID); err != nil {
+		return nil, err
+	}
+	return loadTemplate(s.db, positionID)
+}
+
+// SaveTemplate creates or replaces the position's scorecard template.
+// Evaluations already submitted keep the criteria they were scored against.
+func (s *EvaluationService) SaveTemplate(companyID, positionID uint, input ScorecardInput) (*models.ScorecardTemplate, error) {
+	position, err := s.companyPosition(companyID, positionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Scale == 0 {
+		input.Scale = defaultScorecardScale
+	}
+	if input.Scale < 2 || input.Scale > 10 {
+		return nil, fmt.Errorf("%w: scale must be between 2 and 10", ErrInvalidScorecard)
+	}
+	if len(input.Criteria) == 0 {
+		return nil, fmt.Errorf("%w: at least one criterion is required", ErrInvalidScorecard)
+	}
+	seen := make(map[string]bool)
+	for _, criterion := range input.Criteria {
+		name := strings.TrimSpace(criterion.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: every criterion needs a name", ErrInvalidScorecard)
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("%w: criterion %q is listed twice", ErrInvalidScorecard, name)
+		}
+		seen[strings.ToLower(name)] = true
+		if criterion.Weight <= 0 {
+			return nil, fmt.Errorf("%w: criterion %q needs a positive weight", ErrInvalidScorecard, name)
+		}
+	}
+
+	var template models.ScorecardTemplate
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("position_id = ?", positionID).First(&template).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		template.PositionID = positionID
+		template.CompanyID = position.CompanyID
+		template.Name = input.Name
+		template.Scale = input.Scale
+		template.Criteria = nil
+		if err := tx.Save(&template).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.ScorecardCriterion{}).Error; err != nil {
+			return err
+		}
+		criteria := make([]models.ScorecardCriterion, len(input.Criteria))
+		for i, criterion := range input.Criteria {
+			criteria[i] = models.ScorecardCriterion{
+				TemplateID:      template.ID,
+				Name:            strings.TrimSpace(criterion.Name),
+				Description:     criterion.Description,
+				Weight:          criterion.Weight,
+				CommentRequired: criterion.CommentRequired,
+				SortOrder:       i,
+			}
+		}
+		if err := tx.Create(&criteria).Error; err != nil {
+			return err
+		}
+		template.Criteria = criteria
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Submit evaluates an interview the interviewer has already ended.
+func (s *EvaluationService) Submit(interviewerID, interviewID uint, input EvaluationInput) (*models.Evaluation, error) {
+	var evaluation *models.Evaluation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var interview models.Interview
+		if err := tx.First(&interview, interviewID).Error; err != nil {
+			return ErrInterviewNotFound
+		}
+		if interview.InterviewerID != interviewerID {
+			return ErrNotYourInterview
+		}
+		if interview.Status != models.InterviewCompleted {
+			return fmt.Errorf("%w: only completed interviews can be evaluated", ErrInvalidEvaluation)
+		}
+
+		var err error
+		evaluation, err = createEvaluation(tx, &interview, &input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return evaluation, nil
+}
+
+// ForPosition lists the position's evaluations, newest first, together with a
+// per-candidate comparison ranked by average score.
+func (s *EvaluationService) ForPosition(companyID, positionID uint) ([]models.Evaluation, []CandidateEvaluationSummary, error) {
+	position, err := s.companyPosition(companyID, positionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var evaluations []models.Evaluation
+	if err := s.db.Preload("Candidate").Preload("Interviewer").
+		Preload("Scores", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("position_id = ? AND company_id = ?", positionID, position.CompanyID).
+		Order("created_at DESC").Find(&evaluations).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return evaluations, compareCandidates(evaluations), nil
+}
+
+// companyPosition loads the position if it belongs to the company. Positions
+// of other companies are reported as not found.
+func (s *EvaluationService) companyPosition(companyID, positionID uint) (*models.Position, error) {
+	var position models.Position
+	if err := s.db.Where("id = ? AND company_id = ?", positionID, companyID).First(&position).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPositionNotFound
+		}
+		return nil, err
+	}
+	return &position, nil
+}
+
+func loadTemplate(db *gorm.DB, positionID uint) (*models.ScorecardTemplate, error) {
+	var template models.ScorecardTemplate
+	err := db.Preload("Criteria", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Where("position_id = ?", positionID).First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrScorecardNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// createEvaluation validates the input against the position's scorecard and
+// stores it for the interview. A nil input is accepted only when the position
+// has no scorecard template.
+func createEvaluation(tx *gorm.DB, interview *models.Interview, input *EvaluationInput) (*models.Evaluation, error) {
+	template, err := loadTemplate(tx, interview.PositionID)
+	if err != nil && !errors.Is(err, ErrScorecardNotFound) {
+		return nil, err
+	}
+	if input == nil {
+		if template != nil {
+			return nil, ErrEvaluationRequired
+		}
+		return nil, nil
+	}
+
+	var existing int64
+	if err := tx.Model(&models.Evaluation{}).Where("interview_id = ?", interview.ID).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, ErrAlreadyEvaluated
+	}
+
+	if input.Recommendation != models.RecommendationHire && input.Recommendation != models.RecommendationNoHire {
+		return nil, fmt.Errorf("%w: recommendation must be %q or %q",
+			ErrInvalidEvaluation, models.RecommendationHire, models.RecommendationNoHire)
+	}
+
+	var position models.Position
+	if err := tx.First(&position, interview.PositionID).Error; err != nil {
+		return nil, err
+	}
+
+	evaluation := models.Evaluation{
+		InterviewID:    interview.ID,
+		CandidateID:    interview.CandidateID,
+		InterviewerID:  interview.InterviewerID,
+		PositionID:     interview.PositionID,
+		CompanyID:      position.CompanyID,
+		Recommendation: input.Recommendation,
+		Comments:       input.Comments,
+	}
+
+	if template == nil {
+		if len(input.Scores) > 0 {
+			return nil, fmt.Errorf("%w: position has no scorecard to score against", ErrInvalidEvaluation)
+		}
+	} else {
+		scores, overall, err := scoreEvaluation(template, input.Scores)
+		if err != nil {
+			return nil, err
+		}
+		evaluation.TemplateID = &template.ID
+		evaluation.Scores = scores
+		evaluation.OverallScore = overall
+	}
+
+	if err := tx.Create(&evaluation).Error; err != nil {
+		return nil, err
+	}
+	return &evaluation, nil
+}
+
+// scoreEvaluation checks there is exactly one in-range score per criterion,
+// with a comment where the criterion asks for one, and returns the weighted
+// average on the template's scale.
+func scoreEvaluation(template *models.ScorecardTemplate, inputs []ScoreInput) ([]models.EvaluationScore, float64, error) {
+	given := make(map[uint]ScoreInput, len(inputs))
+	for _, input := range inputs {
+		if _, duplicate := given[input.CriterionID]; duplicate {
+			return nil, 0, fmt.Errorf("%w: criterion %d is scored twice", ErrInvalidEvaluation, input.CriterionID)
+		}
+		given[input.CriterionID] = input
+	}
+
+	scores := make([]models.EvaluationScore, 0, len(template.Criteria))
+	var weighted, totalWeight float64
+	for _, criterion := range template.Criteria {
+		input, ok := given[criterion.ID]
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: %q has no score", ErrInvalidEvaluation, criterion.Name)
+		}
+		delete(given, criterion.ID)
+
+		if input.Score < 1 || input.Score > template.Scale {
+			return nil, 0, fmt.Errorf("%w: %q must be scored from 1 to %d", ErrInvalidEvaluation, criterion.Name, template.Scale)
+		}
+		if criterion.CommentRequired && strings.TrimSpace(input.Comment) == "" {
+			return nil, 0, fmt.Errorf("%w: %q requires a comment", ErrInvalidEvaluation, criterion.Name)
+		}
+
+		scores = append(scores, models.EvaluationScore{
+			CriterionID:   criterion.ID,
+			CriterionName: criterion.Name,
+			Weight:        criterion.Weight,
+			Score:         input.Score,
+			Comment:       input.Comment,
+		})
+		weighted += criterion.Weight * float64(input.Score)
+		totalWeight += criterion.Weight
+	}
+	if len(given) > 0 {
+		return nil, 0, fmt.Errorf("%w: scores given for criteria not on the scorecard", ErrInvalidEvaluation)
+	}
+
+	return scores, weighted / totalWeight, nil
+}
+
+func compareCandidates(evaluations []models.Evaluation) []CandidateEvaluationSummary {
+	type totals struct {
+		summary        *CandidateEvaluationSummary
+		scored         int
+		scoreSum       float64
+		criterionSum   map[string]float64
+		criterionCount map[string]int
+	}
+
+	byCandidate := make(map[uint]*totals)
+	var order []uint
+	for _, evaluation := range evaluations {
+		t, ok := byCandidate[evaluation.CandidateID]
+		if !ok {
+			t = &totals{
+				summary: &CandidateEvaluationSummary{
+					CandidateID:       evaluation.CandidateID,
+					CandidateName:     evaluation.Candidate.Name,
+					CriterionAverages: make(map[string]float64),
+				},
+				criterionSum:   make(map[string]float64),
+				criterionCount: make(map[string]int),
+			}
+			byCandidate[evaluation.CandidateID] = t
+			order = append(order, evaluation.CandidateID)
+		}
+
+		t.summary.Evaluations++
+		if evaluation.Recommendation == models.RecommendationHire {
+			t.summary.Hire++
+		} else {
+			t.summary.NoHire++
+		}
+		if evaluation.TemplateID != nil {
+			t.scored++
+			t.scoreSum += evaluation.OverallScore
+		}
+		for _, score := range evaluation.Scores {
+			t.criterionSum[score.CriterionName] += float64(score.Score)
+			t.criterionCount[score.CriterionName]++
+		}
+	}
+
+	summaries := make([]CandidateEvaluationSummary, 0, len(order))
+	for _, candidateID := range order {
+		t := byCandidate[candidateID]
+		if t.scored > 0 {
+			t.summary.AverageScore = t.scoreSum / float64(t.scored)
+		}
+		for name, sum := range t.criterionSum {
+			t.summary.CriterionAverages[name] = sum / float64(t.criterionCount[name])
+		}
+		summaries = append(summaries, *t.summary)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].AverageScore != summaries[j].AverageScore {
+			return summaries[i].AverageScore > summaries[j].AverageScore
+		}
+		return summaries[i].Hire > summaries[j].Hire
+	})
+	return summaries
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"interview-system/config"
+	"interview-system/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEventNotFound      = errors.New("event not found")
+	ErrNoOpenEvent        = errors.New("no open recruitment event")
+	ErrPositionNotInEvent = errors.New("position is not part of an open recruitment event")
+	ErrEventStillRunning  = errors.New("event is still running, end it before archiving")
+	ErrEventNotRunning    = errors.New("no recruitment event is running")
+)
+
+// openEventStatuses are the statuses in which an event still accepts candidates
+// or is about to.
+var openEventStatuses = []string{models.EventPending, models.EventActive, models.EventClosing, models.EventFinalCall}
+
+type EventService struct {
+	db       *gorm.DB
+	defaults config.QueueConfig
+}
+
+func NewEventService(db *gorm.DB, defaults config.QueueConfig) *EventService {
+	return &EventService{
+		db:       db,
+		defaults: defaults,
+	}
+}
+
+// openEvents scopes a query to non-archived events that are running or
+// upcoming, running ones first and then by start time.
+func (s *EventService) openEvents() *gorm.DB {
+	return s.db.Where("archived_at IS NULL AND status IN ?", openEventStatuses).
+		Order("CASE WHEN status = '" + models.EventPending + "' THEN 1 ELSE 0 END, start_time ASC")
+}
+
+// Current returns the event the system is focused on: the running event if
+// there is one, otherwise the next upcoming one, otherwise the most recent
+// non-archived event.
+func (s *EventService) Current() (*models.Event, error) {
+	var event models.Event
+	err := s.openEvents().First(&event).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = s.db.Where("archived_at IS NULL").Order("start_time DESC").First(&event).Error
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoOpenEvent
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Running returns the current event if it is running at the given time, that
+// is active, closing or in its final call.
+func (s *EventService) Running(now time.Time) (*models.Event, error) {
+	event, err := s.Current()
+	if errors.Is(err, ErrNoOpenEvent) {
+		return nil, ErrEventNotRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch PhaseAt(event, now) {
+	case models.EventActive, models.EventClosing, models.EventFinalCall:
+		return event, nil
+	}
+	return nil, ErrEventNotRunning
+}
+
+func (s *EventService) Get(id uint) (*models.Event, error) {
+	var event models.Event
+	if err := s.db.Preload("Positions").First(&event, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Resolve returns the event with the given ID, or the current event for rows
+// created before events existed (event ID 0).
+func (s *EventService) Resolve(eventID uint) (*models.Event, error) {
+	if eventID == 0 {
+		return s.Current()
+	}
+	return s.Get(eventID)
+}
+
+// ForPosition finds the open event a candidate joins when queueing for the
+// position. An event without any positions attached is open to every position.
+func (s *EventService) ForPosition(positionID uint) (*models.Event, error) {
+	var event models.Event
+	err := s.openEvents().
+		Where("id IN (?) OR id NOT IN (?)",
+			s.db.Table("event_positions").Select("event_id").Where("position_id = ?", positionID),
+			s.db.Table("event_positions").Select("event_id")).
+		First(&event).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPositionNotInEvent
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (s *EventService) List(includeArchived bool) ([]models.Event, error) {
+	var events []models.Event
+	query := s.db.Preload("Positions").Order("start_time DESC")
+	if !includeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Create stores a new event, filling any unset queue parameters from the
+// configured defaults.
+func (s *EventService) Create(event *models.Event, positionIDs []uint) error {
+	if event.ActiveQueueLimit <= 0 {
+		event.ActiveQueueLimit = s.defaults.ActiveQueueLimit
+	}
+	if event.HighPriorityQuota <= 0 {
+		event.HighPriorityQuota = s.defaults.HighPriorityQuota
+	}
+	if event.AverageInterviewTime <= 0 {
+		event.AverageInterviewTime = s.defaults.AverageInterviewTime
+	}
+	if event.BufferTime <= 0 {
+		event.BufferTime = s.defaults.BufferTime
+	}
+	if event.GroupInterviewMaxSize <= 0 {
+		event.GroupInterviewMaxSize = s.defaults.GroupInterviewMaxSize
+	}
+	if event.CallGracePeriod <= 0 {
+		event.CallGracePeriod = s.defaults.CallGracePeriod
+	}
+	if event.Date.IsZero() {
+		event.Date = event.StartTime
+	}
+	if event.Status == "" {
+		event.Status = models.EventPending
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		return setEventPositions(tx, event, positionIDs)
+	})
+}
+
+func (s *EventService) SetPositions(eventID uint, positionIDs []uint) (*models.Event, error) {
+	event, err := s.Get(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setEventPositions(s.db, event, positionIDs); err != nil {
+		return nil, err
+	}
+	return s.Get(eventID)
+}
+
+func (s *EventService) Archive(eventID uint) (*models.Event, error) {
+	event, err := s.Get(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.IsRunning() {
+		return nil, ErrEventStillRunning
+	}
+
+	if event.ArchivedAt == nil {
+		now := time.Now()
+		event.ArchivedAt = &now
+		if err := s.db.Model(event).Update("archived_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+	return event, nil
+}
+
+func setEventPositions(tx *gorm.DB, event *models.Event, positionIDs []uint) error {
+	positions := []models.Position{}
+	if len(positionIDs) > 0 {
+		if err := tx.Where("id IN ?", positionIDs).Find(&positions).Error; err != nil {
+			return err
+		}
+		if len(positions) != len(positionIDs) {
+			return errors.New("one or more positions do not exist")
+		}
+	}
+	return tx.Model(event).Association("Positions").Replace(positions)
+}
@@ -0,0 +1,767 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// groupResponseWindow is how long invited candidates have to accept.
+const groupResponseWindow = 60 * time.Second
+
+var (
+	ErrGroupNotFound        = errors.New("group interview not found")
+	ErrGroupNotOwner        = errors.New("group interview belongs to another interviewer")
+	ErrGroupNotInvited      = errors.New("you were not invited to this group interview")
+	ErrGroupWindowClosed    = errors.New("the response window for this group interview has closed")
+	ErrGroupAlreadyAnswered = errors.New("you have already responded to this invitation")
+	ErrGroupInvalidState    = errors.New("group interview is not in a state that allows this action")
+	ErrGroupNoParticipants  = errors.New("no candidates accepted the group interview")
+	ErrGroupNoCandidates    = errors.New("no waiting candidates to invite")
+)
+
+type GroupInterviewService struct {
+	db           *gorm.DB
+	wsHub        *WebSocketHub
+	events       *EventService
+	cache        *QueueCache
+	schedule     *ScheduleEngine
+	admission    *Admission
+	applications *ApplicationService
+	clock        Clock
+}
+
+func NewGroupInterviewService(db *gorm.DB, wsHub *WebSocketHub, events *EventService, cache *QueueCache, schedule *ScheduleEngine, admission *Admission, applications *ApplicationService, clock Clock) *GroupInterviewService {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &GroupInterviewService{
+		db:           db,
+		wsHub:        wsHub,
+		events:       events,
+		cache:        cache,
+		schedule:     schedule,
+		admission:    admission,
+		applications: applications,
+		clock:        clock,
+	}
+}
+
+// Initiate creates a group interview for the position and invites the given
+// candidates, or everyone waiting in the position queue when none are given.
+// Selection runs automatically once the response window closes.
+func (s *GroupInterviewService) Initiate(interviewerID, positionID uint, maxParticipants int, candidateIDs []uint) (*models.GroupInterview, error) {
+	return s.initiate(interviewerID, positionID, maxParticipants, candidateIDs, "")
+}
+
+// AutoInitiate starts a system-proposed group interview for everyone waiting
+// in the position queue and records why it was formed.
+func (s *GroupInterviewService) AutoInitiate(interviewerID, positionID uint, reason string) (*models.GroupInterview, error) {
+	return s.initiate(interviewerID, positionID, 0, nil, reason)
+}
+
+func (s *GroupInterviewService) initiate(interviewerID, positionID uint, maxParticipants int, candidateIDs []uint, reason string) (*models.GroupInterview, error) {
+	if _, err := InterviewerPositions(s.db, interviewerID, positionID); err != nil {
+		return nil, err
+	}
+
+	event, err := s.events.ForPosition(positionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxParticipants <= 0 || maxParticipants > event.GroupInterviewMaxSize {
+		maxParticipants = event.GroupInterviewMaxSize
+	}
+
+	// Only candidates actually waiting in this queue can be invited
+	query := s.db.Model(&models.QueueEntry{}).
+		Where("event_id = ? AND position_id = ? AND status = ?", event.ID, positionID, "waiting")
+	if len(candidateIDs) > 0 {
+		query = query.Where("candidate_id IN ?", candidateIDs)
+	}
+	var invitees []uint
+	if err := query.Order("queue_position ASC").Pluck("candidate_id", &invitees).Error; err != nil {
+		return nil, err
+	}
+	if len(invitees) == 0 {
+		return nil, ErrGroupNoCandidates
+	}
+
+	now := s.clock.Now()
+	deadline := now.Add(groupResponseWindow)
+	group := models.GroupInterview{
+		EventID:          event.ID,
+		InterviewerID:    interviewerID,
+		PositionID:       positionID,
+		MaxParticipants:  maxParticipants,
+		Status:           models.GroupInviting,
+		ResponseDeadline: &deadline,
+		AutoTriggered:    reason != "",
+		TriggerReason:    reason,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&group).Error; err != nil {
+			return err
+		}
+		for _, candidateID := range invitees {
+			invitation := models.GroupInterviewInvitation{
+				GroupInterviewID: group.ID,
+				CandidateID:      candidateID,
+				Status:           models.InvitationPending,
+			}
+			if err := tx.Create(&invitation).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidateID := range invitees {
+		s.wsHub.BroadcastToUser(candidateID, Message{
+			Type: GroupInvitation,
+			Data: map[string]interface{}{
+				"group_interview_id":      group.ID,
+				"position_id":             positionID,
+				"max_participants":        maxParticipants,
+				"expires_at":              deadline,
+				"response_window_seconds": int(groupResponseWindow.Seconds()),
+			},
+			Timestamp: now,
+		})
+	}
+
+	s.scheduleSelection(group.ID, groupResponseWindow)
+
+	return &group, nil
+}
+
+// ResumePending re-arms the selection timers of groups still collecting
+// responses, e.g. after a restart. Overdue groups are selected immediately.
+func (s *GroupInterviewService) ResumePending() {
+	var groups []models.GroupInterview
+	s.db.Where("status = ?", models.GroupInviting).Find(&groups)
+
+	now := s.clock.Now()
+	for _, group := range groups {
+		wait := time.Duration(0)
+		if group.ResponseDeadline != nil && group.ResponseDeadline.After(now) {
+			wait = group.ResponseDeadline.Sub(now)
+		}
+		s.scheduleSelection(group.ID, wait)
+	}
+}
+
+func (s *GroupInterviewService) scheduleSelection(groupID uint, wait time.Duration) {
+	time.AfterFunc(wait, func() {
+		if _, err := s.FinalizeSelection(groupID); err != nil && !errors.Is(err, ErrGroupInvalidState) {
+			log.Printf("Group interview %d: selection failed: %v", groupID, err)
+		}
+	})
+}
+
+// Respond records a candidate's answer to an invitation. Answers are only
+// accepted while the response window is open.
+func (s *GroupInterviewService) Respond(groupID, candidateID uint, accept bool) (*models.GroupInterviewInvitation, error) {
+	var invitation models.GroupInterviewInvitation
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		group, err := lockGroup(tx, groupID)
+		if err != nil {
+			return err
+		}
+
+		now := s.clock.Now()
+		if group.Status != models.GroupInviting ||
+			(group.ResponseDeadline != nil && now.After(*group.ResponseDeadline)) {
+			return ErrGroupWindowClosed
+		}
+
+		if err := tx.Where("group_interview_id = ? AND candidate_id = ?", groupID, candidateID).
+			First(&invitation).Error; err != nil {
+			return ErrGroupNotInvited
+		}
+		if invitation.Status != models.InvitationPending {
+			return ErrGroupAlreadyAnswered
+		}
+
+		invitation.Status = models.InvitationDeclined
+		if accept {
+			invitation.Status = models.InvitationAccepted
+		}
+		invitation.RespondedAt = &now
+		return tx.Save(&invitation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// FinalizeSelection closes the response window. Accepted candidates are
+// selected in the order they responded, up to the participant cap; the rest
+// are waitlisted as substitutes.
+func (s *GroupInterviewService) FinalizeSelection(groupID uint) (*models.GroupInterview, error) {
+	var group *models.GroupInterview
+	var invitations []models.GroupInterviewInvitation
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		group, err = lockGroup(tx, groupID)
+		if err != nil {
+			return err
+		}
+		if group.Status != models.GroupInviting {
+			return ErrGroupInvalidState
+		}
+
+		if err := tx.Where("group_interview_id = ?", groupID).Find(&invitations).Error; err != nil {
+			return err
+		}
+
+		accepted := make([]*models.GroupInterviewInvitation, 0, len(invitations))
+		for i := range invitations {
+			switch invitations[i].Status {
+			case models.InvitationAccepted:
+				accepted = append(accepted, &invitations[i])
+			case models.InvitationPending:
+				invitations[i].Status = models.InvitationExpired
+			}
+		}
+
+		sort.SliceStable(accepted, func(i, j int) bool {
+			return accepted[i].RespondedAt.Before(*accepted[j].RespondedAt)
+		})
+
+		var participants []models.User
+		for i, invitation := range accepted {
+			if i < group.MaxParticipants {
+				invitation.Status = models.InvitationSelected
+				participants = append(participants, models.User{ID: invitation.CandidateID})
+			} else {
+				invitation.Status = models.InvitationWaitlisted
+			}
+		}
+
+		for i := range invitations {
+			if err := tx.Model(&invitations[i]).Update("status", invitations[i].Status).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(participants) == 0 {
+			// The group trigger's cooldown runs from when the group was called off
+			now := s.clock.Now()
+			group.Status = models.GroupCancelled
+			group.EndTime = &now
+			return tx.Model(group).Updates(map[string]interface{}{
+				"status":   group.Status,
+				"end_time": now,
+			}).Error
+		}
+
+		group.Status = models.GroupReady
+		if err := tx.Model(group).Omit("Participants.*").Association("Participants").Append(participants); err != nil {
+			return err
+		}
+		return tx.Model(group).Update("status", group.Status).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, invitation := range invitations {
+		s.notifySelection(group, invitation.CandidateID, invitation.Status)
+	}
+
+	selected := 0
+	for _, invitation := range invitations {
+		if invitation.Status == models.InvitationSelected {
+			selected++
+		}
+	}
+	s.wsHub.BroadcastToUser(group.InterviewerID, Message{
+		Type: GroupSelection,
+		Data: map[string]interface{}{
+			"group_interview_id": group.ID,
+			"status":             group.Status,
+			"selected_count":     selected,
+		},
+		Timestamp: s.clock.Now(),
+	})
+
+	return group, nil
+}
+
+// Withdraw takes a candidate out of a group interview that has not started
+// yet. If a selected participant withdraws, the earliest waitlisted
+// candidate takes their place.
+func (s *GroupInterviewService) Withdraw(groupID, candidateID uint) error {
+	var group *models.GroupInterview
+	var substitute *models.GroupInterviewInvitation
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		group, err = lockGroup(tx, groupID)
+		if err != nil {
+			return err
+		}
+		if group.Status != models.GroupInviting && group.Status != models.GroupReady {
+			return ErrGroupInvalidState
+		}
+
+		var invitation models.GroupInterviewInvitation
+		if err := tx.Where("group_interview_id = ? AND candidate_id = ?", groupID, candidateID).
+			First(&invitation).Error; err != nil {
+			return ErrGroupNotInvited
+		}
+
+		wasSelected := invitation.Status == models.InvitationSelected
+		switch invitation.Status {
+		case models.InvitationPending, models.InvitationAccepted, models.InvitationSelected, models.InvitationWaitlisted:
+		default:
+			return ErrGroupInvalidState
+		}
+
+		if err := tx.Model(&invitation).Update("status", models.InvitationWithdrawn).Error; err != nil {
+			return err
+		}
+		if !wasSelected {
+			return nil
+		}
+
+		if err := tx.Model(group).Association("Participants").Delete(&models.User{ID: candidateID}); err != nil {
+			return err
+		}
+
+		var next models.GroupInterviewInvitation
+		err = tx.Where("group_interview_id = ? AND status = ?", groupID, models.InvitationWaitlisted).
+			Order("responded_at ASC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&next).Update("status", models.InvitationSelected).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(group).Omit("Participants.*").Association("Participants").Append(&models.User{ID: next.CandidateID}); err != nil {
+			return err
+		}
+		substitute = &next
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if substitute != nil {
+		s.notifySelection(group, substitute.CandidateID, models.InvitationSelected)
+	}
+	s.wsHub.BroadcastToUser(group.InterviewerID, Message{
+		Type: GroupSelection,
+		Data: map[string]interface{}{
+			"group_interview_id": group.ID,
+			"withdrawn":          candidateID,
+			"substitute":         substituteID(substitute),
+		},
+		Timestamp: s.clock.Now(),
+	})
+
+	return nil
+}
+
+// Start begins a ready group interview. Each participant gets an Interview
+// row and their waiting or called queue entry for the position moves to
+// interviewing.
+func (s *GroupInterviewService) Start(groupID, interviewerID uint) (*models.GroupInterview, error) {
+	var group *models.GroupInterview
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		group, err = lockGroup(tx, groupID)
+		if err != nil {
+			return err
+		}
+		if group.InterviewerID != interviewerID {
+			return ErrGroupNotOwner
+		}
+		if group.Status != models.GroupReady {
+			return ErrGroupInvalidState
+		}
+
+		var participants []models.User
+		if err := tx.Model(group).Association("Participants").Find(&participants); err != nil {
+			return err
+		}
+		if len(participants) == 0 {
+			return ErrGroupNoParticipants
+		}
+
+		// Nobody may be in another interview, the interviewer included
+		userIDs := []uint{group.InterviewerID}
+		for _, participant := range participants {
+			userIDs = append(userIDs, participant.ID)
+		}
+		if err := lockUsers(tx, userIDs...); err != nil {
+			return err
+		}
+		for i, participant := range participants {
+			interviewerID := group.InterviewerID
+			if i > 0 {
+				// The interviewer was already checked with the first participant
+				interviewerID = 0
+			}
+			if err := checkInterviewConflict(tx, participant.ID, interviewerID); err != nil {
+				return err
+			}
+		}
+
+		now := s.clock.Now()
+		group.Status = models.GroupInProgress
+		group.StartTime = &now
+		if err := tx.Model(group).Updates(map[string]interface{}{
+			"status":     group.Status,
+			"start_time": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		for i, participant := range participants {
+			// The interview is for the round the participant is queued in
+			var entry models.QueueEntry
+			err := tx.Where("event_id = ? AND candidate_id = ? AND position_id = ? AND status IN ?",
+				group.EventID, participant.ID, group.PositionID, []string{"waiting", "called"}).First(&entry).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			candidateID := participant.ID
+			interview := models.Interview{
+				EventID:          group.EventID,
+				CandidateID:      participant.ID,
+				InterviewerID:    group.InterviewerID,
+				PositionID:       group.PositionID,
+				Round:            entryRound(entry.Round),
+				Status:           models.InterviewInProgress,
+				StartTime:        &now,
+				IsGroupInterview: true,
+				GroupInterviewID: &group.ID,
+				CandidateBusyKey: &candidateID,
+			}
+			if i == 0 {
+				interview.InterviewerBusyKey = &group.InterviewerID
+			}
+			if err := tx.Create(&interview).Error; err != nil {
+				return err
+			}
+			if entry.ID == 0 {
+				continue
+			}
+			if err := tx.Model(&entry).Update("status", "interviewing").Error; err != nil {
+				return err
+			}
+		}
+		group.Participants = participants
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.reschedule(group.EventID, nil)
+	for _, participant := range group.Participants {
+		s.cache.Remove(group.EventID, group.PositionID, participant.ID)
+		s.wsHub.BroadcastToUser(participant.ID, Message{
+			Type: InterviewStatus,
+			Data: map[string]interface{}{
+				"group_interview_id": group.ID,
+				"status":             "started",
+			},
+			Timestamp: s.clock.Now(),
+		})
+	}
+
+	return group, nil
+}
+
+// GroupResult is the interviewer's verdict on one group interview participant.
+// The evaluation is required when the position has a scorecard template and
+// then decides whether the participant passed.
+type GroupResult struct {
+	CandidateID uint             `json:"candidate_id"`
+	Passed      bool             `json:"passed"`
+	Evaluation  *EvaluationInput `json:"evaluation"`
+}
+
+// End completes a running group interview and every participant's interview
+// and queue entry in one transaction. Participants are judged one by one, the
+// same way a one-on-one interview ends: each one's evaluation is checked
+// against the position's scorecard, and those who pass a round that has a
+// next round are queued for it. Without a scorecard, participants left out of
+// results get no verdict.
+func (s *GroupInterviewService) End(groupID, interviewerID uint, notes string, results []GroupResult) (*models.GroupInterview, []*models.Evaluation, error) {
+	var group *models.GroupInterview
+	var evaluations []*models.Evaluation
+	var candidateIDs []uint
+	var applications []*models.CandidatePosition
+	var nextEntries []*models.QueueEntry
+	nextRounds := make(map[uint]*models.PositionRound)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		group, err = lockGroup(tx, groupID)
+		if err != nil {
+			return err
+		}
+		if group.InterviewerID != interviewerID {
+			return ErrGroupNotOwner
+		}
+		if group.Status != models.GroupInProgress {
+			return ErrGroupInvalidState
+		}
+
+		now := s.clock.Now()
+		duration := 0
+		if group.StartTime != nil {
+			duration = int(now.Sub(*group.StartTime).Minutes())
+		}
+
+		group.Status = models.GroupCompleted
+		group.EndTime = &now
+		if err := tx.Model(group).Updates(map[string]interface{}{
+			"status":   group.Status,
+			"end_time": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		var interviews []models.Interview
+		if err := tx.Where("group_interview_id = ? AND status = ?", group.ID, models.InterviewInProgress).
+			Order("id ASC").Find(&interviews).Error; err != nil {
+			return err
+		}
+
+		verdicts := make(map[uint]GroupResult, len(results))
+		for _, result := range results {
+			verdicts[result.CandidateID] = result
+		}
+
+		for i := range interviews {
+			interview := &interviews[i]
+			candidateIDs = append(candidateIDs, interview.CandidateID)
+
+			var passed *bool
+			verdict, ok := verdicts[interview.CandidateID]
+			if ok {
+				passed = &verdict.Passed
+			}
+
+			evaluation, err := createEvaluation(tx, interview, verdict.Evaluation)
+			if err != nil {
+				return fmt.Errorf("candidate %d: %w", interview.CandidateID, err)
+			}
+			if evaluation != nil {
+				hire := evaluation.Recommendation == models.RecommendationHire
+				passed = &hire
+				evaluations = append(evaluations, evaluation)
+			}
+
+			updates := models.ReleaseInterviewKeys()
+			updates["status"] = models.InterviewCompleted
+			updates["end_time"] = now
+			updates["duration"] = duration
+			updates["notes"] = notes
+			updates["passed"] = passed
+			if err := tx.Model(interview).Updates(updates).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.QueueEntry{}).
+				Where("event_id = ? AND candidate_id = ? AND position_id = ? AND status = ?",
+					group.EventID, interview.CandidateID, group.PositionID, "interviewing").
+				Updates(models.CloseQueueEntry("completed")).Error; err != nil {
+				return err
+			}
+
+			var next *models.PositionRound
+			if passed != nil && *passed {
+				var entry *models.QueueEntry
+				if entry, next, err = queueNextRound(tx, interview); err != nil {
+					return err
+				}
+				if entry != nil {
+					nextEntries = append(nextEntries, entry)
+					nextRounds[interview.CandidateID] = next
+				}
+			}
+
+			application, err := s.applications.InterviewEnded(tx, interview.CandidateID, group.PositionID, passed, next)
+			if err != nil {
+				return err
+			}
+			applications = append(applications, application)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range nextEntries {
+		s.cache.Add(entry)
+	}
+	s.reschedule(group.EventID, func() {
+		// Finishing frees an active slot for the candidates' other queues
+		for _, candidateID := range candidateIDs {
+			if err := s.admission.Recompute(group.EventID, candidateID); err != nil {
+				log.Printf("Group interview: failed to recompute active queues for candidate %d: %v", candidateID, err)
+			}
+		}
+	})
+	for _, candidateID := range candidateIDs {
+		s.wsHub.BroadcastToUser(candidateID, Message{
+			Type: InterviewStatus,
+			Data: map[string]interface{}{
+				"group_interview_id": group.ID,
+				"status":             "completed",
+			},
+			Timestamp: s.clock.Now(),
+		})
+		if next := nextRounds[candidateID]; next != nil {
+			s.wsHub.BroadcastToUser(candidateID, Message{
+				Type: InterviewStatus,
+				Data: map[string]interface{}{
+					"group_interview_id": group.ID,
+					"status":             "next_round",
+					"position_id":        group.PositionID,
+					"round":              next.Number,
+					"round_name":         next.Name,
+					"message":            fmt.Sprintf("You passed and are now queued for %s", next.Name),
+				},
+				Timestamp: s.clock.Now(),
+			})
+		}
+	}
+	for _, application := range applications {
+		s.applications.Notify(application)
+	}
+	s.wsHub.BroadcastToAll(Message{
+		Type:      QueueUpdate,
+		Data:      map[string]interface{}{"position_id": group.PositionID},
+		Timestamp: s.clock.Now(),
+	})
+
+	return group, evaluations, nil
+}
+
+// Get returns a group interview to its interviewer or to a candidate who was
+// invited to it.
+func (s *GroupInterviewService) Get(groupID, userID uint) (*models.GroupInterview, error) {
+	var group models.GroupInterview
+	if err := s.db.Preload("Position").Preload("Participants").Preload("Invitations.Candidate").
+		First(&group, groupID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+
+	if group.InterviewerID == userID {
+		return &group, nil
+	}
+	for _, invitation := range group.Invitations {
+		if invitation.CandidateID == userID {
+			return &group, nil
+		}
+	}
+	return nil, ErrGroupNotOwner
+}
+
+// List returns group interviews, newest first, optionally narrowed to one
+// event and to the ones the system formed automatically.
+func (s *GroupInterviewService) List(eventID uint, autoOnly bool) ([]models.GroupInterview, error) {
+	var groups []models.GroupInterview
+	query := s.db.Preload("Position").Preload("Interviewer").Preload("Participants").Order("created_at DESC")
+	if eventID > 0 {
+		query = query.Where("event_id = ?", eventID)
+	}
+	if autoOnly {
+		query = query.Where("auto_triggered = ?", true)
+	}
+	err := query.Find(&groups).Error
+	return groups, err
+}
+
+// PendingInvitations lists the open invitations a candidate can still answer.
+func (s *GroupInterviewService) PendingInvitations(candidateID uint) ([]models.GroupInterview, error) {
+	var groups []models.GroupInterview
+	err := s.db.Preload("Position").
+		Where("status = ? AND id IN (?)", models.GroupInviting,
+			s.db.Model(&models.GroupInterviewInvitation{}).Select("group_interview_id").
+				Where("candidate_id = ? AND status = ?", candidateID, models.InvitationPending)).
+		Find(&groups).Error
+	return groups, err
+}
+
+func (s *GroupInterviewService) notifySelection(group *models.GroupInterview, candidateID uint, status string) {
+	var message string
+	switch status {
+	case models.InvitationSelected:
+		message = "You have been selected for the group interview"
+	case models.InvitationWaitlisted:
+		message = "The group interview is full, you are on the substitute list"
+	case models.InvitationExpired:
+		message = "The group interview invitation expired"
+	default:
+		return
+	}
+
+	s.wsHub.BroadcastToUser(candidateID, Message{
+		Type: GroupSelection,
+		Data: map[string]interface{}{
+			"group_interview_id": group.ID,
+			"position_id":        group.PositionID,
+			"status":             status,
+			"message":            message,
+		},
+		Timestamp: s.clock.Now(),
+	})
+}
+
+// reschedule requests a recompute of the event's projected timeline; then,
+// if not nil, runs once it is done.
+func (s *GroupInterviewService) reschedule(eventID uint, then func()) {
+	s.schedule.Request(eventID, then)
+}
+
+func lockGroup(tx *gorm.DB, groupID uint) (*models.GroupInterview, error) {
+	var group models.GroupInterview
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, groupID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("failed to load group interview: %w", err)
+	}
+	return &group, nil
+}
+
+func substituteID(invitation *models.GroupInterviewInvitation) interface{} {
+	if invitation == nil {
+		return nil
+	}
+	return invitation.CandidateID
+}
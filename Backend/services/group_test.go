@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"interview-system/models"
+	"testing"
+	"time"
+)
+
+// seedRunningGroup starts a group interview for two candidates of a position
+// with a one-criterion scorecard.
+func seedRunningGroup(t *testing.T, s *QueueService) (*GroupInterviewService, *models.GroupInterview, []uint, uint) {
+	t.Helper()
+
+	event, positionID, users := seedQueue(t, s, 3)
+	interviewerID, candidates := users[0], users[1:]
+
+	var position models.Position
+	s.db.First(&position, positionID)
+	template := models.ScorecardTemplate{
+		PositionID: positionID,
+		CompanyID:  position.CompanyID,
+		Name:       "Engineering",
+		Scale:      5,
+		Criteria:   []models.ScorecardCriterion{{Name: "Design", Weight: 1}},
+	}
+	if err := s.db.Create(&template).Error; err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+
+	start := time.Now().Add(-20 * time.Minute)
+	group := models.GroupInterview{EventID: event.ID, InterviewerID: interviewerID, PositionID: positionID,
+		Status: models.GroupInProgress, StartTime: &start}
+	if err := s.db.Create(&group).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	for _, candidateID := range candidates {
+		if err := s.db.Create(&models.Interview{EventID: event.ID, CandidateID: candidateID, InterviewerID: interviewerID,
+			PositionID: positionID, Status: models.InterviewInProgress, StartTime: &start,
+			IsGroupInterview: true, GroupInterviewID: &group.ID}).Error; err != nil {
+			t.Fatalf("create interview: %v", err)
+		}
+	}
+
+	groups := NewGroupInterviewService(s.db, s.wsHub, s.events, s.cache, s.schedule, s.admission, s.applications, nil)
+	return groups, &group, candidates, template.Criteria[0].ID
+}
+
+func TestGroupEndRequiresAScorecardPerParticipant(t *testing.T) {
+	s := newTestQueueService(t)
+	groups, group, candidates, criterionID := seedRunningGroup(t, s)
+
+	evaluation := func(recommendation string, score int) *EvaluationInput {
+		return &EvaluationInput{Recommendation: recommendation, Scores: []ScoreInput{{CriterionID: criterionID, Score: score}}}
+	}
+
+	tests := []struct {
+		name    string
+		results []GroupResult
+		want    error
+	}{
+		{
+			name:    "no evaluations",
+			results: []GroupResult{{CandidateID: candidates[0], Passed: true}, {CandidateID: candidates[1], Passed: true}},
+			want:    ErrEvaluationRequired,
+		},
+		{
+			name: "one participant left out",
+			results: []GroupResult{
+				{CandidateID: candidates[0], Evaluation: evaluation(models.RecommendationHire, 4)},
+			},
+			want: ErrEvaluationRequired,
+		},
+		{
+			name: "score off the scale",
+			results: []GroupResult{
+				{CandidateID: candidates[0], Evaluation: evaluation(models.RecommendationHire, 4)},
+				{CandidateID: candidates[1], Evaluation: evaluation(models.RecommendationNoHire, 9)},
+			},
+			want: ErrInvalidEvaluation,
+		},
+	}
+	for _, tt := range tests {
+		if _, _, err := groups.End(group.ID, group.InterviewerID, "", tt.results); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: End = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	var running int64
+	s.db.Model(&models.Interview{}).Where("group_interview_id = ? AND status = ?", group.ID, models.InterviewInProgress).Count(&running)
+	if running != int64(len(candidates)) {
+		t.Fatalf("%d interviews still running after rejected ends, want %d", running, len(candidates))
+	}
+
+	// The evaluation decides the verdict, whatever passed says
+	_, evaluations, err := groups.End(group.ID, group.InterviewerID, "", []GroupResult{
+		{CandidateID: candidates[0], Passed: false, Evaluation: evaluation(models.RecommendationHire, 4)},
+		{CandidateID: candidates[1], Passed: true, Evaluation: evaluation(models.RecommendationNoHire, 2)},
+	})
+	if err != nil {
+		t.Fatalf("End: %v", err)
+	}
+	if len(evaluations) != 2 {
+		t.Fatalf("%d evaluations stored, want 2", len(evaluations))
+	}
+	for i, want := range []bool{true, false} {
+		var interview models.Interview
+		s.db.Where("group_interview_id = ? AND candidate_id = ?", group.ID, candidates[i]).First(&interview)
+		if interview.Passed == nil {
+			t.Errorf("candidate %d got no verdict, want passed = %v", candidates[i], want)
+		} else if *interview.Passed != want {
+			t.Errorf("candidate %d passed = %v, want %v", candidates[i], *interview.Passed, want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"interview-system/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// groupTriggerWindow is how close to the end of an event the detector
+	// starts proposing group interviews.
+	groupTriggerWindow = 10 * time.Minute
+	// groupTriggerMinInterview is how long a busy interviewer's current
+	// interview must have run before a group is proposed for them.
+	groupTriggerMinInterview = 5 * time.Minute
+	// groupTriggerMinWaiting is the smallest queue worth a group interview.
+	groupTriggerMinWaiting = 2
+	// groupTriggerCooldown is how long after an automatic group for a position
+	// was cancelled or completed before another one is proposed for it.
+	groupTriggerCooldown = 5 * time.Minute
+)
+
+// GroupTrigger watches every interviewer's queue as an event draws to a close
+// and proposes a group interview when the remaining candidates could not
+// otherwise all be seen one by one.
+type GroupTrigger struct {
+	db       *gorm.DB
+	groups   *GroupInterviewService
+	clock    Clock
+	interval time.Duration
+}
+
+func NewGroupTrigger(db *gorm.DB, groups *GroupInterviewService, clock Clock) *GroupTrigger {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &GroupTrigger{
+		db:       db,
+		groups:   groups,
+		clock:    clock,
+		interval: 15 * time.Second,
+	}
+}
+
+func (t *GroupTrigger) Run() {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		t.Tick()
+	}
+}
+
+// Tick inspects all running events once.
+func (t *GroupTrigger) Tick() {
+	var events []models.Event
+	if err := t.db.Where("archived_at IS NULL AND status IN ?",
+		[]string{models.EventActive, models.EventClosing, models.EventFinalCall}).
+		Find(&events).Error; err != nil {
+		log.Printf("Group trigger: failed to load events: %v", err)
+		return
+	}
+
+	now := t.clock.Now()
+	for i := range events {
+		remaining := events[i].EndTime.Sub(now)
+		if remaining <= 0 || remaining > groupTriggerWindow {
+			continue
+		}
+		t.checkEvent(&events[i], remaining, now)
+	}
+}
+
+func (t *GroupTrigger) checkEvent(event *models.Event, remaining time.Duration, now time.Time) {
+	var assignments []models.PositionInterviewer
+	if err := t.db.Find(&assignments).Error; err != nil {
+		log.Printf("Group trigger: failed to load interviewer assignments: %v", err)
+		return
+	}
+
+	// Pick, per interviewer, the assigned position with the longest queue
+	type candidatePosition struct {
+		positionID uint
+		waiting    int64
+	}
+	best := make(map[uint]candidatePosition)
+	for _, assignment := range assignments {
+		var waiting int64
+		t.db.Model(&models.QueueEntry{}).
+			Where("event_id = ? AND position_id = ? AND status = ?", event.ID, assignment.PositionID, "waiting").
+			Count(&waiting)
+		if waiting > best[assignment.InterviewerID].waiting {
+			best[assignment.InterviewerID] = candidatePosition{positionID: assignment.PositionID, waiting: waiting}
+		}
+	}
+
+	for interviewerID, choice := range best {
+		if choice.waiting < groupTriggerMinWaiting {
+			continue
+		}
+
+		var openGroups int64
+		t.db.Model(&models.GroupInterview{}).
+			Where("event_id = ? AND interviewer_id = ? AND status IN ?", event.ID, interviewerID,
+				[]string{models.GroupInviting, models.GroupReady, models.GroupInProgress}).
+			Count(&openGroups)
+		if openGroups > 0 {
+			continue
+		}
+
+		// Don't invite the same queue again right after a group fell through
+		var recentGroups int64
+		t.db.Model(&models.GroupInterview{}).
+			Where("event_id = ? AND interviewer_id = ? AND position_id = ? AND auto_triggered = ? AND status IN ? AND end_time > ?",
+				event.ID, interviewerID, choice.positionID, true,
+				[]string{models.GroupCancelled, models.GroupCompleted}, now.Add(-groupTriggerCooldown)).
+			Count(&recentGroups)
+		if recentGroups > 0 {
+			continue
+		}
+
+		var reason string
+		var current models.Interview
+		err := t.db.Where("interviewer_id = ? AND status = ?", interviewerID, models.InterviewInProgress).
+			First(&current).Error
+		switch {
+		case err != nil:
+			reason = fmt.Sprintf("%d min left in event, interviewer idle with %d candidates waiting",
+				int(remaining.Minutes()), choice.waiting)
+		case current.StartTime != nil && now.Sub(*current.StartTime) >= groupTriggerMinInterview:
+			reason = fmt.Sprintf("%d min left in event, current interview running %d min with %d candidates waiting",
+				int(remaining.Minutes()), int(now.Sub(*current.StartTime).Minutes()), choice.waiting)
+		default:
+			// Let the current interview reach the minimum length first
+			continue
+		}
+
+		group, err := t.groups.AutoInitiate(interviewerID, choice.positionID, reason)
+		if err != nil {
+			log.Printf("Group trigger: could not start group for interviewer %d on position %d: %v",
+				interviewerID, choice.positionID, err)
+			continue
+		}
+		log.Printf("Group trigger: group interview %d proposed for interviewer %d on position %d (%s)",
+			group.ID, interviewerID, choice.positionID, reason)
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"interview-system/models"
+	"testing"
+	"time"
+)
+
+func TestGroupTriggerCoolsDownAfterCancelledGroup(t *testing.T) {
+	s := newTestQueueService(t)
+	event, positionID, users := seedQueue(t, s, 4)
+	interviewerID := users[0]
+	if err := s.db.Create(&models.PositionInterviewer{PositionID: positionID, InterviewerID: interviewerID}).Error; err != nil {
+		t.Fatalf("assign interviewer: %v", err)
+	}
+	for i, candidateID := range users[1:] {
+		addWaiting(t, s, models.QueueEntry{EventID: event.ID, CandidateID: candidateID, PositionID: positionID,
+			Priority: models.PriorityRegular, JoinTime: event.StartTime.Add(time.Duration(i) * time.Minute)})
+	}
+
+	clock := newFakeClock(time.Now())
+	if err := s.db.Model(event).Updates(map[string]interface{}{
+		"end_time":                 clock.Now().Add(9 * time.Minute),
+		"group_interview_max_size": 3,
+	}).Error; err != nil {
+		t.Fatalf("update event: %v", err)
+	}
+
+	groups := NewGroupInterviewService(s.db, s.wsHub, s.events, s.cache, s.schedule, s.admission, s.applications, clock)
+	trigger := NewGroupTrigger(s.db, groups, clock)
+
+	countGroups := func() int64 {
+		var n int64
+		s.db.Model(&models.GroupInterview{}).Where("interviewer_id = ? AND position_id = ?", interviewerID, positionID).Count(&n)
+		return n
+	}
+
+	trigger.Tick()
+	var group models.GroupInterview
+	if err := s.db.Where("interviewer_id = ?", interviewerID).First(&group).Error; err != nil {
+		t.Fatalf("no group proposed: %v", err)
+	}
+
+	// Nobody accepts, so the group is called off
+	clock.Advance(time.Minute)
+	if _, err := groups.FinalizeSelection(group.ID); err != nil {
+		t.Fatalf("FinalizeSelection: %v", err)
+	}
+	s.db.First(&group, group.ID)
+	if group.Status != models.GroupCancelled {
+		t.Fatalf("group is %s, want %s", group.Status, models.GroupCancelled)
+	}
+
+	steps := []struct {
+		advance time.Duration
+		want    int64
+	}{
+		{15 * time.Second, 1},
+		{groupTriggerCooldown - 30*time.Second, 1},
+		{time.Minute, 2},
+	}
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		trigger.Tick()
+		if got := countGroups(); got != step.want {
+			t.Fatalf("%v after the cancellation: %d groups, want %d",
+				clock.Now().Sub(*group.EndTime), got, step.want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	ImportCreated = "created"
+	ImportValid   = "valid"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// requiredImportColumns must be present in the header row; password,
+// company_code, employee_id, email and phone are optional. Candidate rows
+// need a password, interviewer rows are invited and never take one.
+var requiredImportColumns = []string{"account", "name", "role"}
+
+type ImportService struct {
+	db          *gorm.DB
+	authService *AuthService
+	invitations *InvitationService
+}
+
+// ImportRowResult reports one row. Interviewers are created through an
+// invitation, whose token is only ever shown here.
+type ImportRowResult struct {
+	Row                 int        `json:"row"`
+	Account             string     `json:"account"`
+	Role                string     `json:"role"`
+	Status              string     `json:"status"`
+	Message             string     `json:"message,omitempty"`
+	UserID              uint       `json:"user_id,omitempty"`
+	InvitationToken     string     `json:"invitation_token,omitempty"`
+	InvitationExpiresAt *time.Time `json:"invitation_expires_at,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Valid   int               `json:"valid"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+func NewImportService(db *gorm.DB, authService *AuthService, invitations *InvitationService) *ImportService {
+	return &ImportService{
+		db:          db,
+		authService: authService,
+		invitations: invitations,
+	}
+}
+
+// ImportUsers reads a CSV or XLSX file and creates one candidate or interviewer
+// per row. Rows are handled independently, so a bad row never blocks the rest
+// of the sheet. With dryRun set every row is validated but nothing is written.
+// Candidates get the password from the sheet; interviewers are invited by
+// createdBy, so that they choose their own.
+func (s *ImportService) ImportUsers(createdBy uint, filename string, r io.Reader, dryRun bool) (*ImportReport, error) {
+	records, err := readImportFile(filename, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("import file is empty")
+	}
+
+	columns, err := mapImportHeader(records[0])
+	if err != nil {
+		return nil, err
+	}
+
+	companies, err := s.loadCompanyCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: dryRun, Rows: []ImportRowResult{}}
+	seen := make(map[string]int)
+
+	for i, record := range records[1:] {
+		rowNumber := i + 2 // 1-based, counting the header row
+		if isBlankRecord(record) {
+			continue
+		}
+
+		field := func(name string) string {
+			idx, ok := columns[name]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+
+		result := ImportRowResult{
+			Row:     rowNumber,
+			Account: field("account"),
+			Role:    strings.ToLower(field("role")),
+		}
+		report.Total++
+
+		user, err := buildImportUser(field, companies)
+		if err != nil {
+			result.Status = ImportFailed
+			result.Message = err.Error()
+			report.add(result)
+			continue
+		}
+
+		if firstRow, ok := seen[user.Account]; ok {
+			result.Status = ImportFailed
+			result.Message = fmt.Sprintf("duplicate account, already used on row %d", firstRow)
+			report.add(result)
+			continue
+		}
+		seen[user.Account] = rowNumber
+
+		var existing int64
+		if err := s.db.Unscoped().Model(&models.User{}).Where("account = ?", user.Account).Count(&existing).Error; err != nil {
+			result.Status = ImportFailed
+			result.Message = "failed to check for an existing account: " + err.Error()
+			report.add(result)
+			continue
+		}
+		if existing > 0 {
+			result.Status = ImportSkipped
+			result.Message = "account already exists"
+			report.add(result)
+			continue
+		}
+
+		if dryRun {
+			result.Status = ImportValid
+			report.add(result)
+			continue
+		}
+
+		if user.Role == models.RoleInterviewer {
+			invited, token, invitation, err := s.invitations.Invite(createdBy, InviteInput{
+				Account:    user.Account,
+				Name:       user.Name,
+				Role:       user.Role,
+				CompanyID:  user.CompanyID,
+				EmployeeID: user.EmployeeID,
+				Email:      user.Email,
+				Phone:      user.Phone,
+			})
+			if err != nil {
+				result.Status = ImportFailed
+				result.Message = err.Error()
+				report.add(result)
+				continue
+			}
+			result.Status = ImportCreated
+			result.UserID = invited.ID
+			result.InvitationToken = token
+			result.InvitationExpiresAt = &invitation.ExpiresAt
+			report.add(result)
+			continue
+		}
+
+		hashedPassword, err := s.authService.HashPassword(user.Password)
+		if err != nil {
+			result.Status = ImportFailed
+			result.Message = "failed to hash password"
+			report.add(result)
+			continue
+		}
+		user.Password = hashedPassword
+
+		if err := s.db.Create(user).Error; err != nil {
+			result.Status = ImportFailed
+			result.Message = err.Error()
+			report.add(result)
+			continue
+		}
+
+		result.Status = ImportCreated
+		result.UserID = user.ID
+		report.add(result)
+	}
+
+	return report, nil
+}
+
+func (r *ImportReport) add(result ImportRowResult) {
+	switch result.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportValid:
+		r.Valid++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+func (s *ImportService) loadCompanyCodes() (map[string]models.Company, error) {
+	var companies []models.Company
+	if err := s.db.Find(&companies).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make(map[string]models.Company, len(companies))
+	for _, company := range companies {
+		codes[strings.ToUpper(company.Code)] = company
+	}
+	return codes, nil
+}
+
+func buildImportUser(field func(string) string, companies map[string]models.Company) (*models.User, error) {
+	account := field("account")
+	if account == "" {
+		return nil, errors.New("account is required")
+	}
+
+	name := field("name")
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	role := models.UserRole(strings.ToLower(field("role")))
+	if role != models.RoleCandidate && role != models.RoleInterviewer {
+		return nil, fmt.Errorf("role must be %s or %s", models.RoleCandidate, models.RoleInterviewer)
+	}
+
+	// Nobody but the interviewer may know their password
+	password := field("password")
+	switch {
+	case role == models.RoleInterviewer && password != "":
+		return nil, errors.New("interviewers are invited and set their own password, leave password empty")
+	case role == models.RoleCandidate && len(password) < 6:
+		return nil, errors.New("password must be at least 6 characters")
+	}
+
+	user := &models.User{
+		Account:    account,
+		Password:   password,
+		Name:       name,
+		EmployeeID: field("employee_id"),
+		Role:       role,
+		Email:      field("email"),
+		Phone:      field("phone"),
+		IsActive:   true,
+	}
+
+	code := strings.ToUpper(field("company_code"))
+	if code != "" {
+		company, ok := companies[code]
+		if !ok {
+			return nil, fmt.Errorf("unknown company code %q", code)
+		}
+		if !company.IsActive {
+			return nil, fmt.Errorf("company %q is not active", code)
+		}
+		user.CompanyID = &company.ID
+	}
+
+	if role == models.RoleInterviewer && user.CompanyID == nil {
+		return nil, errors.New("company_code is required for interviewers")
+	}
+
+	return user, nil
+}
+
+func readImportFile(filename string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV file: %w", err)
+		}
+		return records, nil
+
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid XLSX file: %w", err)
+		}
+		defer f.Close()
+
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("XLSX file has no sheets")
+		}
+		records, err := f.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %q: %w", sheets[0], err)
+		}
+		return records, nil
+
+	default:
+		return nil, errors.New("unsupported file type, expected .csv or .xlsx")
+	}
+}
+
+func mapImportHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		key = strings.ReplaceAll(key, " ", "_")
+		columns[key] = i
+	}
+
+	for _, required := range requiredImportColumns {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+	return columns, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
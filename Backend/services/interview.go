@@ -0,0 +1,353 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidPosition     = errors.New("invalid position ID")
+	ErrInvalidCandidate    = errors.New("invalid candidate ID")
+	ErrPositionNotAssigned = errors.New("you are not assigned to this position")
+	ErrInterviewNotRunning = errors.New("interview is not in progress")
+)
+
+// InterviewConflictError is returned when an interview can't start because
+// the candidate or the interviewer is already in another one, or the
+// candidate was called by another interviewer.
+type InterviewConflictError struct {
+	Interview models.Interview
+	// CandidateBusy is true when the candidate is the one already being
+	// interviewed, false when it is the interviewer
+	CandidateBusy bool
+	// Call is the candidate's open call by another interviewer, if that is
+	// the conflict
+	Call *models.QueueEntry
+}
+
+func (e *InterviewConflictError) Error() string {
+	if e.Call != nil && e.Call.CalledBy != nil {
+		return fmt.Sprintf("candidate %d was called by interviewer %d", e.Call.CandidateID, *e.Call.CalledBy)
+	}
+	if e.CandidateBusy {
+		return fmt.Sprintf("candidate %d is already in interview %d with interviewer %d",
+			e.Interview.CandidateID, e.Interview.ID, e.Interview.InterviewerID)
+	}
+	return fmt.Sprintf("interviewer %d already has interview %d in progress",
+		e.Interview.InterviewerID, e.Interview.ID)
+}
+
+// InterviewService starts and ends one-to-one interviews. A candidate and an
+// interviewer can each be in only one interview at a time; this is checked
+// under row locks and backed by unique indexes on the interview's busy keys.
+type InterviewService struct {
+	db           *gorm.DB
+	wsHub        *WebSocketHub
+	events       *EventService
+	cache        *QueueCache
+	schedule     *ScheduleEngine
+	admission    *Admission
+	applications *ApplicationService
+}
+
+func NewInterviewService(db *gorm.DB, wsHub *WebSocketHub, events *EventService, cache *QueueCache, schedule *ScheduleEngine, admission *Admission, applications *ApplicationService) *InterviewService {
+	return &InterviewService{
+		db:           db,
+		wsHub:        wsHub,
+		events:       events,
+		cache:        cache,
+		schedule:     schedule,
+		admission:    admission,
+		applications: applications,
+	}
+}
+
+// Start begins an interview with the candidate for the position and takes
+// their waiting or called queue entry in the running event out of the queue.
+// The interviewer must be assigned to the round the candidate is queued for,
+// and a called candidate can only be started by the interviewer who called
+// them.
+func (s *InterviewService) Start(interviewerID, candidateID, positionID uint) (*models.Interview, error) {
+	event, err := s.events.Running(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var position models.Position
+	if err := s.db.First(&position, positionID).Error; err != nil {
+		return nil, ErrInvalidPosition
+	}
+
+	var candidate models.User
+	if err := s.db.First(&candidate, candidateID).Error; err != nil {
+		return nil, ErrInvalidCandidate
+	}
+
+	pool, err := InterviewerRoundPool(s.db, interviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var interview models.Interview
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUsers(tx, candidateID, interviewerID); err != nil {
+			return err
+		}
+
+		if err := checkInterviewConflict(tx, candidateID, interviewerID); err != nil {
+			return err
+		}
+
+		// A called candidate turning up is started the same way as one picked
+		// from the queue, but only by the interviewer who called them
+		var entry models.QueueEntry
+		if err := tx.Where("event_id = ? AND candidate_id = ? AND position_id = ? AND status IN ?",
+			event.ID, candidateID, positionID, []string{"waiting", "called"}).First(&entry).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotInQueue
+			}
+			return err
+		}
+		if entry.Status == "called" && entry.CalledBy != nil && *entry.CalledBy != interviewerID {
+			return &InterviewConflictError{Call: &entry}
+		}
+		round := entryRound(entry.Round)
+		if !pool.Covers(positionID, round) {
+			return ErrPositionNotAssigned
+		}
+
+		now := time.Now()
+		interview = models.Interview{
+			EventID:            event.ID,
+			CandidateID:        candidateID,
+			InterviewerID:      interviewerID,
+			PositionID:         positionID,
+			Round:              round,
+			Status:             models.InterviewInProgress,
+			StartTime:          &now,
+			CandidateBusyKey:   &candidateID,
+			InterviewerBusyKey: &interviewerID,
+		}
+		if err := tx.Create(&interview).Error; err != nil {
+			// A racing start slipped past the locks, report what it collided with
+			if conflict := checkInterviewConflict(s.db, candidateID, interviewerID); conflict != nil {
+				return conflict
+			}
+			return err
+		}
+
+		return tx.Model(&entry).Update("status", "interviewing").Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Remove(interview.EventID, positionID, candidateID)
+	s.schedule.Request(interview.EventID, nil)
+
+	s.wsHub.BroadcastToUser(candidateID, Message{
+		Type: InterviewStatus,
+		Data: map[string]interface{}{
+			"interview_id": interview.ID,
+			"status":       "started",
+		},
+		Timestamp: time.Now(),
+	})
+
+	return &interview, nil
+}
+
+// End completes the interviewer's interview, stores their evaluation with it,
+// closes the candidate's queue entry and moves their application on. The
+// evaluation may only be left out when the position has no scorecard
+// template. The candidate passes when the evaluation recommends hiring them,
+// or without one when passed is true; passing a round that has a next round
+// queues them for it.
+func (s *InterviewService) End(interviewerID, interviewID uint, notes string, passed *bool, input *EvaluationInput) (*models.Interview, *models.Evaluation, error) {
+	var interview models.Interview
+	var evaluation *models.Evaluation
+	var nextEntry *models.QueueEntry
+	var next *models.PositionRound
+	var application *models.CandidatePosition
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&interview, interviewID).Error; err != nil {
+			return ErrInterviewNotFound
+		}
+		if interview.InterviewerID != interviewerID {
+			return ErrNotYourInterview
+		}
+		if interview.Status != models.InterviewInProgress {
+			return ErrInterviewNotRunning
+		}
+
+		var err error
+		if evaluation, err = createEvaluation(tx, &interview, input); err != nil {
+			return err
+		}
+		if evaluation != nil {
+			hire := evaluation.Recommendation == models.RecommendationHire
+			passed = &hire
+		}
+
+		now := time.Now()
+		interview.EndTime = &now
+		if interview.StartTime != nil {
+			interview.Duration = int(now.Sub(*interview.StartTime).Minutes())
+		}
+		interview.Status = models.InterviewCompleted
+		interview.Notes = notes
+		interview.Passed = passed
+		interview.CandidateBusyKey = nil
+		interview.InterviewerBusyKey = nil
+		if err := tx.Save(&interview).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.QueueEntry{}).
+			Where("candidate_id = ? AND position_id = ? AND status IN ?",
+				interview.CandidateID, interview.PositionID, []string{"waiting", "called", "interviewing"}).
+			Updates(models.CloseQueueEntry("completed")).Error; err != nil {
+			return err
+		}
+
+		if passed != nil && *passed {
+			if nextEntry, next, err = queueNextRound(tx, &interview); err != nil {
+				return err
+			}
+		}
+
+		application, err = s.applications.InterviewEnded(tx, interview.CandidateID, interview.PositionID, passed, next)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if nextEntry != nil {
+		s.cache.Add(nextEntry)
+	}
+	s.schedule.Request(interview.EventID, func() {
+		if err := s.admission.Recompute(interview.EventID, interview.CandidateID); err != nil {
+			log.Printf("Failed to recompute admission for candidate %d: %v", interview.CandidateID, err)
+		}
+	})
+	s.applications.Notify(application)
+
+	if nextEntry != nil {
+		s.wsHub.BroadcastToUser(interview.CandidateID, Message{
+			Type: InterviewStatus,
+			Data: map[string]interface{}{
+				"interview_id": interview.ID,
+				"status":       "next_round",
+				"position_id":  interview.PositionID,
+				"round":        next.Number,
+				"round_name":   next.Name,
+				"message":      fmt.Sprintf("You passed and are now queued for %s", next.Name),
+			},
+			Timestamp: time.Now(),
+		})
+		s.wsHub.BroadcastToAll(Message{
+			Type:      QueueUpdate,
+			Data:      map[string]interface{}{"position_id": interview.PositionID},
+			Timestamp: time.Now(),
+		})
+	}
+
+	return &interview, evaluation, nil
+}
+
+// InterviewerPositions returns the positions the interviewer is assigned to in
+// ascending order, or just positionID when it is non-zero and one of them.
+func InterviewerPositions(db *gorm.DB, interviewerID uint, positionID uint) ([]uint, error) {
+	var positionIDs []uint
+	if err := db.Model(&models.PositionInterviewer{}).Where("interviewer_id = ?", interviewerID).
+		Order("position_id ASC").Distinct().Pluck("position_id", &positionIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(positionIDs) == 0 {
+		return nil, ErrNoAssignedPosition
+	}
+	if positionID == 0 {
+		return positionIDs, nil
+	}
+	for _, id := range positionIDs {
+		if id == positionID {
+			return []uint{positionID}, nil
+		}
+	}
+	return nil, ErrPositionNotAssigned
+}
+
+// BusyCandidates returns which of the candidates are being interviewed or
+// have been called by an interviewer right now.
+func BusyCandidates(db *gorm.DB, candidateIDs []uint) (map[uint]bool, error) {
+	busy := make(map[uint]bool)
+	if len(candidateIDs) == 0 {
+		return busy, nil
+	}
+
+	var interviewing []uint
+	if err := db.Model(&models.Interview{}).
+		Where("candidate_id IN ? AND status = ?", candidateIDs, models.InterviewInProgress).
+		Pluck("candidate_id", &interviewing).Error; err != nil {
+		return nil, err
+	}
+	var called []uint
+	if err := db.Model(&models.QueueEntry{}).
+		Where("candidate_id IN ? AND status IN ?", candidateIDs, []string{"called", "interviewing"}).
+		Pluck("candidate_id", &called).Error; err != nil {
+		return nil, err
+	}
+
+	for _, id := range append(interviewing, called...) {
+		busy[id] = true
+	}
+	return busy, nil
+}
+
+// checkInterviewConflict returns an *InterviewConflictError if the candidate
+// or the interviewer already has an interview in progress.
+func checkInterviewConflict(tx *gorm.DB, candidateID, interviewerID uint) error {
+	var current models.Interview
+	err := tx.Where("candidate_id = ? AND status = ?", candidateID, models.InterviewInProgress).First(&current).Error
+	if err == nil {
+		return &InterviewConflictError{Interview: current, CandidateBusy: true}
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	err = tx.Where("interviewer_id = ? AND status = ?", interviewerID, models.InterviewInProgress).First(&current).Error
+	if err == nil {
+		return &InterviewConflictError{Interview: current}
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// lockUsers takes row locks on the users in ascending ID order, so
+// transactions locking the same pair never deadlock.
+func lockUsers(tx *gorm.DB, userIDs ...uint) error {
+	sorted := append([]uint(nil), userIDs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for i, userID := range sorted {
+		if i > 0 && userID == sorted[i-1] {
+			continue
+		}
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"errors"
+	"interview-system/models"
+	"testing"
+	"time"
+)
+
+func TestInterviewStartChecksTheQueueEntry(t *testing.T) {
+	s := newTestQueueService(t)
+	event, positionID, users := seedQueue(t, s, 4)
+	caller, other, candidateID, unqueued := users[0], users[1], users[2], users[3]
+	for _, interviewerID := range []uint{caller, other} {
+		if err := s.db.Create(&models.PositionInterviewer{PositionID: positionID, InterviewerID: interviewerID}).Error; err != nil {
+			t.Fatalf("assign interviewer: %v", err)
+		}
+	}
+	addWaiting(t, s, models.QueueEntry{EventID: event.ID, CandidateID: candidateID, PositionID: positionID,
+		Priority: models.PriorityRegular, JoinTime: time.Now().Add(-time.Minute)})
+
+	calls := NewCallService(s.db, s.wsHub, s.events, s.cache, s.schedule, s.admission, nil)
+	if _, err := calls.CallNext(caller, positionID); err != nil {
+		t.Fatalf("CallNext: %v", err)
+	}
+
+	interviews := NewInterviewService(s.db, s.wsHub, s.events, s.cache, s.schedule, s.admission, s.applications)
+
+	if _, err := interviews.Start(other, unqueued, positionID); !errors.Is(err, ErrNotInQueue) {
+		t.Errorf("Start without a queue entry = %v, want %v", err, ErrNotInQueue)
+	}
+
+	var conflict *InterviewConflictError
+	if _, err := interviews.Start(other, candidateID, positionID); !errors.As(err, &conflict) || conflict.Call == nil {
+		t.Fatalf("Start by another interviewer = %v, want a call conflict", err)
+	}
+
+	interview, err := interviews.Start(caller, candidateID, positionID)
+	if err != nil {
+		t.Fatalf("Start by the caller: %v", err)
+	}
+	if interview.EventID != event.ID {
+		t.Errorf("interview is in event %d, want %d", interview.EventID, event.ID)
+	}
+}
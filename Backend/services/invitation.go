@@ -0,0 +1,296 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// invitationLifetime is how long an invitation link can be used.
+const invitationLifetime = 72 * time.Hour
+
+var (
+	ErrAccountExists       = errors.New("account already exists")
+	ErrInvalidInvitee      = errors.New("invalid invitee")
+	ErrInvalidInvitation   = errors.New("invalid or expired invitation")
+	ErrNoPendingInvitation = errors.New("user has no pending invitation")
+	ErrInvitationPending   = errors.New("user has not accepted their invitation yet, reissue it instead")
+)
+
+// InviteInput describes a privileged account an administrator creates.
+type InviteInput struct {
+	Account    string          `json:"account" binding:"required"`
+	Name       string          `json:"name" binding:"required"`
+	Role       models.UserRole `json:"role" binding:"required"`
+	CompanyID  *uint           `json:"company_id"`
+	EmployeeID string          `json:"employee_id"`
+	Email      string          `json:"email"`
+	Phone      string          `json:"phone"`
+}
+
+// InvitationService creates interviewer and admin accounts on behalf of an
+// administrator. The account starts inactive with an unusable password and
+// is activated when its owner accepts the invitation and picks a password,
+// so no administrator ever knows another user's password.
+type InvitationService struct {
+	db          *gorm.DB
+	authService *AuthService
+}
+
+func NewInvitationService(db *gorm.DB, authService *AuthService) *InvitationService {
+	return &InvitationService{db: db, authService: authService}
+}
+
+// Invite creates the account and returns the raw invitation token, which is
+// only ever shown here.
+func (s *InvitationService) Invite(createdBy uint, input InviteInput) (*models.User, string, *models.Invitation, error) {
+	if !input.Role.Valid() {
+		return nil, "", nil, fmt.Errorf("%w: unknown role %q", ErrInvalidInvitee, input.Role)
+	}
+	if input.Role == models.RoleCandidate {
+		return nil, "", nil, fmt.Errorf("%w: candidates register themselves", ErrInvalidInvitee)
+	}
+
+	needsCompany := input.Role == models.RoleInterviewer || input.Role == models.RoleCompanyAdmin
+	if needsCompany && input.CompanyID == nil {
+		return nil, "", nil, fmt.Errorf("%w: company_id is required for %s", ErrInvalidInvitee, input.Role)
+	}
+	if !needsCompany {
+		input.CompanyID = nil
+	}
+	if input.CompanyID != nil {
+		var company models.Company
+		if err := s.db.Where("is_active = ?", true).First(&company, *input.CompanyID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, "", nil, fmt.Errorf("%w: company %d not found", ErrInvalidInvitee, *input.CompanyID)
+			}
+			return nil, "", nil, err
+		}
+	}
+
+	var existing int64
+	s.db.Unscoped().Model(&models.User{}).Where("account = ?", input.Account).Count(&existing)
+	if existing > 0 {
+		return nil, "", nil, ErrAccountExists
+	}
+
+	// Nobody knows this password; it only keeps the column non-empty
+	placeholder, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	hashedPassword, err := s.authService.HashPassword(placeholder)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	user := models.User{
+		Account:    input.Account,
+		Password:   hashedPassword,
+		Name:       input.Name,
+		EmployeeID: input.EmployeeID,
+		Role:       input.Role,
+		CompanyID:  input.CompanyID,
+		Email:      input.Email,
+		Phone:      input.Phone,
+		IsActive:   false,
+	}
+
+	var token string
+	var invitation *models.Invitation
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(&user).Error; err != nil {
+			return err
+		}
+		// Create skips the false IsActive in favour of the column default
+		if err := tx.Model(&user).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		var err error
+		token, invitation, err = s.issue(tx, createdBy, user.ID, false)
+		return err
+	})
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return &user, token, invitation, nil
+}
+
+// Reissue replaces a user's pending invitation, e.g. when it expired before
+// it was accepted.
+func (s *InvitationService) Reissue(createdBy, userID uint) (string, *models.Invitation, error) {
+	var token string
+	var invitation *models.Invitation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var pending []models.Invitation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).Find(&pending).Error; err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return ErrNoPendingInvitation
+		}
+		for _, previous := range pending {
+			if previous.AcceptedAt != nil {
+				return ErrNoPendingInvitation
+			}
+		}
+
+		if err := tx.Model(&models.Invitation{}).
+			Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+			Update("expires_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		var err error
+		token, invitation, err = s.issue(tx, createdBy, userID, false)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return token, invitation, nil
+}
+
+// ResetPassword replaces the password of an account that has already been set
+// up with one nobody knows, signs the user out everywhere and returns a reset
+// token for them to choose a new password with. Accounts still waiting for
+// their first invitation to be accepted get it reissued instead.
+func (s *InvitationService) ResetPassword(createdBy, userID uint) (string, *models.Invitation, error) {
+	placeholder, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	hashedPassword, err := s.authService.HashPassword(placeholder)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var token string
+	var invitation *models.Invitation
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		var previous []models.Invitation
+		if err := tx.Where("user_id = ?", userID).Find(&previous).Error; err != nil {
+			return err
+		}
+		accepted := len(previous) == 0
+		for _, earlier := range previous {
+			if earlier.AcceptedAt != nil {
+				accepted = true
+			}
+		}
+		if !accepted {
+			return ErrInvitationPending
+		}
+
+		if err := tx.Model(&models.Invitation{}).
+			Where("user_id = ? AND accepted_at IS NULL AND expires_at > ?", userID, time.Now()).
+			Update("expires_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+
+		token, invitation, err = s.issue(tx, createdBy, userID, true)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.authService.RevokeUser(userID); err != nil {
+		return "", nil, err
+	}
+	return token, invitation, nil
+}
+
+// Lookup returns the invitation behind a token, with the invited user.
+func (s *InvitationService) Lookup(token string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	if err := s.db.Preload("User.Company").
+		Where("token_hash = ?", hashToken(token)).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+	if invitation.AcceptedAt != nil || time.Now().After(invitation.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+	return &invitation, nil
+}
+
+// Accept sets the invited user's password and activates the account. Each
+// invitation can be accepted once.
+func (s *InvitationService) Accept(token, password string) (*models.User, error) {
+	hashedPassword, err := s.authService.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var invitation models.Invitation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashToken(token)).First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidInvitation
+			}
+			return err
+		}
+
+		now := time.Now()
+		if invitation.AcceptedAt != nil || now.After(invitation.ExpiresAt) {
+			return ErrInvalidInvitation
+		}
+
+		if err := tx.First(&user, invitation.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidInvitation
+			}
+			return err
+		}
+
+		updates := map[string]interface{}{"password": hashedPassword}
+		if !invitation.Reset {
+			updates["is_active"] = true
+		}
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&invitation).Update("accepted_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *InvitationService) issue(tx *gorm.DB, createdBy, userID uint, reset bool) (string, *models.Invitation, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	invitation := models.Invitation{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		CreatedBy: createdBy,
+		Reset:     reset,
+		ExpiresAt: time.Now().Add(invitationLifetime),
+	}
+	if err := tx.Create(&invitation).Error; err != nil {
+		return "", nil, err
+	}
+	return token, &invitation, nil
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JumpAheadSuggestion describes what jumping ahead in one queue would gain.
+type JumpAheadSuggestion struct {
+	EventID         uint       `json:"event_id"`
+	PositionID      uint       `json:"position_id"`
+	PositionName    string     `json:"position_name"`
+	CurrentPosition int        `json:"current_position"`
+	NewPosition     int        `json:"new_position"`
+	TimeSaved       int        `json:"time_saved"`
+	CurrentETA      *time.Time `json:"current_eta"`
+	NewETA          *time.Time `json:"new_eta"`
+}
+
+// jumpAheadPositions returns where the entry stands in the ordered waiting
+// list and where it would stand after jumping ahead, which passes every
+// regular candidate in front of it.
+func jumpAheadPositions(waiting []models.QueueEntry, entryID uint) (current, jumped int) {
+	jumped = 1
+	for i := range waiting {
+		if waiting[i].ID == entryID {
+			return i + 1, jumped
+		}
+		if waiting[i].Priority >= models.PriorityJumpAhead {
+			jumped++
+		}
+	}
+	return 0, 0
+}
+
+// jumpAheadSource returns the longest projected wait, in minutes, among the
+// candidate's high priority entries in the event. A regular entry can only
+// jump ahead into that wait; ok is false when there is no such entry.
+func (s *QueueService) jumpAheadSource(candidateID uint, event *models.Event) (wait int, ok bool) {
+	var sources []models.QueueEntry
+	if err := s.db.Where("event_id = ? AND candidate_id = ? AND status = ? AND priority = ?",
+		event.ID, candidateID, "waiting", models.PriorityHigh).Find(&sources).Error; err != nil {
+		log.Printf("Failed to load high priority entries of candidate %d: %v", candidateID, err)
+		return 0, false
+	}
+	for i := range sources {
+		if w := s.projectedWait(&sources[i], event.AverageInterviewTime); !ok || w > wait {
+			wait, ok = w, true
+		}
+	}
+	return wait, ok
+}
+
+// jumpAheadSaving is how much of the high priority wait a jump ahead puts to
+// use: the high priority wait minus the regular wait from the place the entry
+// would jump to. It is only worth it from one interview plus buffer.
+func jumpAheadSaving(sourceWait, jumped int, event *models.Event) (saved int, ok bool) {
+	saved = sourceWait - (jumped-1)*event.AverageInterviewTime
+	return saved, saved >= event.AverageInterviewTime+event.BufferTime
+}
+
+// jumpAheadSuggestion works out the jump ahead for one regular entry without
+// changing anything. It returns nil if the entry can't jump ahead, the
+// candidate has no high priority entry to jump from, or the saving is below
+// the threshold.
+func (s *QueueService) jumpAheadSuggestion(entry *models.QueueEntry, event *models.Event) *JumpAheadSuggestion {
+	if entry.JumpAheadUsed || entry.Priority != models.PriorityRegular {
+		return nil
+	}
+
+	sourceWait, ok := s.jumpAheadSource(entry.CandidateID, event)
+	if !ok {
+		return nil
+	}
+
+	var waiting []models.QueueEntry
+	s.db.Select("id", "priority").
+		Where("event_id = ? AND position_id = ? AND status = ?", entry.EventID, entry.PositionID, "waiting").
+		Order(models.QueueOrder).Find(&waiting)
+
+	current, jumped := jumpAheadPositions(waiting, entry.ID)
+	if current == 0 || jumped >= current {
+		return nil
+	}
+	timeSaved, ok := jumpAheadSaving(sourceWait, jumped, event)
+	if !ok {
+		return nil
+	}
+
+	suggestion := &JumpAheadSuggestion{
+		EventID:         entry.EventID,
+		PositionID:      entry.PositionID,
+		PositionName:    entry.Position.Name,
+		CurrentPosition: current,
+		NewPosition:     jumped,
+		TimeSaved:       timeSaved,
+		CurrentETA:      entry.EstimatedTime,
+	}
+	eta := time.Now().Add(time.Duration((jumped-1)*event.AverageInterviewTime) * time.Minute)
+	if entry.EstimatedTime == nil || eta.Before(*entry.EstimatedTime) {
+		suggestion.NewETA = &eta
+	} else {
+		suggestion.NewETA = entry.EstimatedTime
+	}
+	return suggestion
+}
+
+// JumpAheadSuggestion returns the jump ahead available for the position, or
+// the best one across the candidate's queues when positionID is 0. It never
+// changes queue state; nil means nothing worthwhile is available.
+//
+// Only the candidate's regular entries can jump ahead, and only into the wait
+// for one of their high priority entries in the same event.
+func (s *QueueService) JumpAheadSuggestion(candidateID uint, positionID uint) (*JumpAheadSuggestion, error) {
+	query := s.db.Preload("Position").
+		Where("candidate_id = ? AND status = ? AND priority = ?", candidateID, "waiting", models.PriorityRegular)
+	if positionID > 0 {
+		query = query.Where("position_id = ?", positionID)
+	}
+	var entries []models.QueueEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	var best *JumpAheadSuggestion
+	for i := range entries {
+		event, err := s.events.Resolve(entries[i].EventID)
+		if err != nil {
+			return nil, err
+		}
+		if s.policy.Allow(event, ActionJumpAhead) != nil {
+			continue
+		}
+		if suggestion := s.jumpAheadSuggestion(&entries[i], event); suggestion != nil &&
+			(best == nil || suggestion.TimeSaved > best.TimeSaved) {
+			best = suggestion
+		}
+	}
+	return best, nil
+}
+
+// suggestJumpAhead runs after the candidate's queues change. It pushes the
+// best jump ahead over WebSocket, once per position, and expires earlier
+// suggestions that no longer apply.
+func (s *QueueService) suggestJumpAhead(candidateID uint) {
+	suggestion, err := s.JumpAheadSuggestion(candidateID, 0)
+	if err != nil {
+		log.Printf("Failed to check jump ahead for candidate %d: %v", candidateID, err)
+		return
+	}
+
+	stale := s.db.Model(&models.QueueOptimization{}).
+		Where("candidate_id = ? AND type = ? AND result = ?", candidateID, OptimizationJumpAhead, OptimizationSuggested)
+	if suggestion != nil {
+		stale = stale.Where("position_id <> ?", suggestion.PositionID)
+	}
+	stale.Update("result", OptimizationExpired)
+
+	if suggestion == nil {
+		return
+	}
+
+	var pending int64
+	s.db.Model(&models.QueueOptimization{}).
+		Where("candidate_id = ? AND type = ? AND position_id = ? AND result = ?",
+			candidateID, OptimizationJumpAhead, suggestion.PositionID, OptimizationSuggested).
+		Count(&pending)
+	if pending > 0 {
+		return
+	}
+
+	s.db.Omit(clause.Associations).Create(&models.QueueOptimization{
+		EventID:     suggestion.EventID,
+		CandidateID: candidateID,
+		PositionID:  suggestion.PositionID,
+		Type:        OptimizationJumpAhead,
+		Details:     fmt.Sprintf("Move from #%d to #%d in %s", suggestion.CurrentPosition, suggestion.NewPosition, suggestion.PositionName),
+		Result:      OptimizationSuggested,
+		BeforeETA:   suggestion.CurrentETA,
+		AfterETA:    suggestion.NewETA,
+		TimeSaved:   suggestion.TimeSaved,
+		ActorRole:   ActorSystem,
+	})
+
+	s.wsHub.BroadcastToUser(candidateID, Message{
+		Type: JumpAheadSuggested,
+		Data: map[string]interface{}{
+			"suggestion": suggestion,
+			"message": fmt.Sprintf("You can jump ahead in %s and save about %d minutes",
+				suggestion.PositionName, suggestion.TimeSaved),
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// ProcessJumpAhead confirms a jump ahead: the entry moves into the jump ahead
+// priority level for good and the confirmation is recorded. It returns an
+// error when the activity policy forbids the action or the database fails;
+// ordinary "not possible" outcomes are reported through the message.
+func (s *QueueService) ProcessJumpAhead(candidateID uint, positionID uint) (bool, string, error) {
+	var entry models.QueueEntry
+	if err := s.db.Preload("Position").Where("candidate_id = ? AND position_id = ? AND status = ?",
+		candidateID, positionID, "waiting").First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, "Not in queue", nil
+		}
+		return false, "", err
+	}
+
+	activity, err := s.events.Resolve(entry.EventID)
+	if err != nil {
+		return false, "", err
+	}
+
+	if err := s.policy.Allow(activity, ActionJumpAhead); err != nil {
+		return false, err.Error(), err
+	}
+
+	if entry.JumpAheadUsed {
+		return false, "Jump ahead already used", nil
+	}
+	if entry.Priority >= models.PriorityJumpAhead {
+		return false, "Already ahead of regular candidates", nil
+	}
+
+	sourceWait, ok := s.jumpAheadSource(candidateID, activity)
+	if !ok {
+		return false, "Jump ahead needs a high priority position to jump from", nil
+	}
+
+	var currentPos, timeSaved int
+	var record models.QueueOptimization
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCandidate(tx, candidateID); err != nil {
+			return err
+		}
+
+		var waiting []models.QueueEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("event_id = ? AND position_id = ? AND status = ?", entry.EventID, positionID, "waiting").
+			Order(models.QueueOrder).Find(&waiting).Error; err != nil {
+			return err
+		}
+
+		var newPos int
+		currentPos, newPos = jumpAheadPositions(waiting, entry.ID)
+		if currentPos == 0 {
+			return ErrNotInQueue
+		}
+
+		if newPos >= currentPos {
+			return nil
+		}
+		var worth bool
+		if timeSaved, worth = jumpAheadSaving(sourceWait, newPos, activity); !worth {
+			return nil
+		}
+
+		now := time.Now()
+		entry.Priority = models.PriorityJumpAhead
+		entry.TimeSaved = timeSaved
+		entry.PrioritySetTime = &now
+		entry.JumpAheadUsed = true
+		if err := tx.Model(&entry).Updates(map[string]interface{}{
+			"priority":          models.PriorityJumpAhead,
+			"time_saved":        timeSaved,
+			"priority_set_time": now,
+			"jump_ahead_used":   true,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Confirm the pushed suggestion, or record the jump if none was pushed
+		err := tx.Where("candidate_id = ? AND type = ? AND position_id = ? AND result = ?",
+			candidateID, OptimizationJumpAhead, positionID, OptimizationSuggested).
+			First(&record).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			record = models.QueueOptimization{
+				EventID:     entry.EventID,
+				CandidateID: candidateID,
+				PositionID:  positionID,
+				Type:        OptimizationJumpAhead,
+				Details:     fmt.Sprintf("Move from #%d to #%d in %s", currentPos, newPos, entry.Position.Name),
+			}
+		}
+		actorID := candidateID
+		record.Result = OptimizationConfirmed
+		record.BeforeETA = entry.EstimatedTime
+		record.TimeSaved = timeSaved
+		record.ActorID = &actorID
+		record.ActorRole = ActorCandidate
+		record.ConfirmedAt = &now
+		if err := tx.Omit(clause.Associations).Save(&record).Error; err != nil {
+			return err
+		}
+
+		return reorderQueue(tx, entry.EventID, positionID)
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotInQueue) {
+			return false, "Not in queue", nil
+		}
+		return false, "", err
+	}
+	if !entry.JumpAheadUsed {
+		if currentPos <= 1 {
+			return false, "Already at front of queue", nil
+		}
+		return false, "No better position available", nil
+	}
+
+	s.cache.Add(&entry)
+	s.reschedule(entry.EventID, candidateID)
+	s.schedule.Request(entry.EventID, func() { recordAfterETA(s.db, record.ID, entry.ID) })
+	s.broadcastQueueUpdate(positionID)
+	return true, fmt.Sprintf("Jump ahead successful, saving about %d minutes", timeSaved), nil
+}
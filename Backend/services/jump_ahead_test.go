@@ -0,0 +1,121 @@
+package services
+
+import (
+	"interview-system/models"
+	"testing"
+	"time"
+)
+
+// seedJumpAhead queues the last of the candidates behind the others for a
+// regular position and, when highWait is not negative, for a second position
+// as high priority with highWait minutes to wait. It returns the candidate and
+// the regular position.
+func seedJumpAhead(t *testing.T, s *QueueService, highWait int) (*models.Event, uint, uint) {
+	t.Helper()
+
+	event, regularID, candidates := seedQueue(t, s, 6)
+	candidateID := candidates[len(candidates)-1]
+
+	var regular models.Position
+	s.db.First(&regular, regularID)
+	high := models.Position{Name: "Designer", CompanyID: regular.CompanyID}
+	if err := s.db.Create(&high).Error; err != nil {
+		t.Fatalf("create position: %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	for i, id := range candidates {
+		addWaiting(t, s, models.QueueEntry{
+			EventID:     event.ID,
+			CandidateID: id,
+			PositionID:  regularID,
+			Priority:    models.PriorityRegular,
+			JoinTime:    start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	if highWait >= 0 {
+		// Everyone in front waits with high priority too
+		ahead := highWait / event.AverageInterviewTime
+		for i := 0; i < ahead; i++ {
+			set := start.Add(time.Duration(i) * time.Minute)
+			addWaiting(t, s, models.QueueEntry{
+				EventID:         event.ID,
+				CandidateID:     candidates[i],
+				PositionID:      high.ID,
+				Priority:        models.PriorityHigh,
+				IsHighPriority:  true,
+				PrioritySetTime: &set,
+				JoinTime:        set,
+			})
+		}
+		set := start.Add(30 * time.Minute)
+		addWaiting(t, s, models.QueueEntry{
+			EventID:         event.ID,
+			CandidateID:     candidateID,
+			PositionID:      high.ID,
+			Priority:        models.PriorityHigh,
+			IsHighPriority:  true,
+			PrioritySetTime: &set,
+			JoinTime:        set,
+		})
+	}
+	return event, candidateID, regularID
+}
+
+func TestJumpAheadSuggestion(t *testing.T) {
+	tests := []struct {
+		name      string
+		highWait  int // -1 for no high priority entry
+		wantSaved int // 0 for no suggestion
+	}{
+		{"no high priority source", -1, 0},
+		{"threshold not met", 15, 0},
+		{"high priority wait covers the jump", 45, 45},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestQueueService(t)
+			_, candidateID, regularID := seedJumpAhead(t, s, tt.highWait)
+
+			suggestion, err := s.JumpAheadSuggestion(candidateID, regularID)
+			if err != nil {
+				t.Fatalf("JumpAheadSuggestion: %v", err)
+			}
+			if tt.wantSaved == 0 {
+				if suggestion != nil {
+					t.Fatalf("got suggestion %+v, want none", suggestion)
+				}
+			} else {
+				if suggestion == nil {
+					t.Fatal("got no suggestion")
+				}
+				if suggestion.TimeSaved != tt.wantSaved || suggestion.CurrentPosition != 6 || suggestion.NewPosition != 1 {
+					t.Errorf("got %+v, want #6 to #1 saving %d", suggestion, tt.wantSaved)
+				}
+			}
+
+			ok, message, err := s.ProcessJumpAhead(candidateID, regularID)
+			if err != nil {
+				t.Fatalf("ProcessJumpAhead: %v", err)
+			}
+			if ok != (tt.wantSaved > 0) {
+				t.Fatalf("ProcessJumpAhead = %v (%s), want %v", ok, message, tt.wantSaved > 0)
+			}
+
+			var entry models.QueueEntry
+			s.db.Where("candidate_id = ? AND position_id = ?", candidateID, regularID).First(&entry)
+			if !ok {
+				if entry.Priority != models.PriorityRegular || entry.JumpAheadUsed {
+					t.Errorf("entry changed without a jump ahead: %+v", entry)
+				}
+				return
+			}
+			if entry.Priority != models.PriorityJumpAhead || entry.TimeSaved != tt.wantSaved || entry.QueuePosition != 1 {
+				t.Errorf("entry is priority %d, saved %d, at #%d; want %d, %d, #1",
+					entry.Priority, entry.TimeSaved, entry.QueuePosition, models.PriorityJumpAhead, tt.wantSaved)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// accountFailureLimit and ipFailureLimit are how many failed logins within
+	// failureWindow lock an account or client IP out. An IP gets more room, as
+	// one office may share it.
+	accountFailureLimit = 5
+	ipFailureLimit      = 20
+	failureWindow       = 15 * time.Minute
+	// The first lockout lasts baseLockout and each further one twice as long
+	// as the last, up to maxLockout. A key that stays quiet for lockoutDecay
+	// starts over.
+	baseLockout  = time.Minute
+	maxLockout   = 24 * time.Hour
+	lockoutDecay = 24 * time.Hour
+	// distributedAttackIPs is how many client IPs must have failed on a
+	// locked out account for it to count as an attack rather than a user who
+	// forgot their password.
+	distributedAttackIPs = 3
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid account or password")
+	ErrLoginLocked        = errors.New("too many failed logins, try again later")
+	ErrLockoutNotFound    = errors.New("lockout not found")
+)
+
+// LockoutError is returned while an account or IP is locked out. It matches
+// ErrLoginLocked.
+type LockoutError struct {
+	Until time.Time
+}
+
+func (e *LockoutError) Error() string {
+	return ErrLoginLocked.Error()
+}
+
+func (e *LockoutError) Is(target error) bool {
+	return target == ErrLoginLocked
+}
+
+// LoginGuard records every login attempt and locks out accounts and client
+// IPs that keep failing. Counters live in the database so every instance
+// enforces the same lockouts. Control admins are notified when a lockout
+// looks like an attack: an IP failing across accounts, or one account
+// failing from many IPs.
+type LoginGuard struct {
+	db            *gorm.DB
+	notifications *NotificationService
+	clock         Clock
+}
+
+func NewLoginGuard(db *gorm.DB, notifications *NotificationService, clock Clock) *LoginGuard {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &LoginGuard{db: db, notifications: notifications, clock: clock}
+}
+
+// Check returns a *LockoutError if the account or the IP is locked out.
+func (g *LoginGuard) Check(account, ip string) error {
+	var lockouts []models.LoginLockout
+	if err := g.db.Where("((kind = ? AND value = ?) OR (kind = ? AND value = ?)) AND locked_until > ?",
+		models.LockoutAccount, account, models.LockoutIP, ip, g.clock.Now()).
+		Find(&lockouts).Error; err != nil {
+		return err
+	}
+
+	var until time.Time
+	for _, lockout := range lockouts {
+		if lockout.LockedUntil.After(until) {
+			until = *lockout.LockedUntil
+		}
+	}
+	if until.IsZero() {
+		return nil
+	}
+	return &LockoutError{Until: until}
+}
+
+// Succeeded records a successful login and forgets the account's failures.
+// The IP keeps its lockout history, so one good login doesn't let it resume
+// guessing at full speed.
+func (g *LoginGuard) Succeeded(userID uint, account, ip, userAgent string) {
+	g.record(&userID, account, ip, userAgent, models.LoginSuccess)
+
+	if err := g.db.Where("kind = ? AND value = ?", models.LockoutAccount, account).
+		Delete(&models.LoginLockout{}).Error; err != nil {
+		log.Printf("Login guard: failed to reset account %s: %v", account, err)
+	}
+	if err := g.db.Model(&models.LoginLockout{}).
+		Where("kind = ? AND value = ?", models.LockoutIP, ip).
+		Update("failures", 0).Error; err != nil {
+		log.Printf("Login guard: failed to reset IP %s: %v", ip, err)
+	}
+}
+
+// Refused records an attempt turned away by a lockout.
+func (g *LoginGuard) Refused(account, ip, userAgent string) {
+	g.record(nil, account, ip, userAgent, models.LoginLocked)
+}
+
+// Failed records a failed login and counts it against both the account and
+// the IP. userID is nil when the account doesn't exist.
+func (g *LoginGuard) Failed(userID *uint, account, ip, userAgent string) {
+	g.record(userID, account, ip, userAgent, models.LoginFailed)
+
+	if locked, err := g.countFailure(models.LockoutIP, ip, ipFailureLimit); err != nil {
+		log.Printf("Login guard: failed to count failure for IP %s: %v", ip, err)
+	} else if locked != nil {
+		g.alertIP(locked)
+	}
+
+	// Unknown accounts are still counted, so probing for them gets locked out
+	// the same way and doesn't reveal which accounts exist
+	if locked, err := g.countFailure(models.LockoutAccount, account, accountFailureLimit); err != nil {
+		log.Printf("Login guard: failed to count failure for account %s: %v", account, err)
+	} else if locked != nil {
+		g.alertAccount(locked)
+	}
+}
+
+// Lockouts lists current lockouts, or with all set every key with recent
+// failures too.
+func (g *LoginGuard) Lockouts(all bool) ([]models.LoginLockout, error) {
+	now := g.clock.Now()
+	query := g.db.Where("locked_until > ?", now)
+	if all {
+		query = g.db.Where("locked_until > ? OR last_failure_at > ?", now, now.Add(-failureWindow))
+	}
+
+	var lockouts []models.LoginLockout
+	if err := query.Order("last_failure_at DESC").Find(&lockouts).Error; err != nil {
+		return nil, err
+	}
+	return lockouts, nil
+}
+
+// Clear lifts a lockout and forgets its history.
+func (g *LoginGuard) Clear(lockoutID uint) error {
+	result := g.db.Delete(&models.LoginLockout{}, lockoutID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLockoutNotFound
+	}
+	return nil
+}
+
+func (g *LoginGuard) record(userID *uint, account, ip, userAgent, status string) {
+	if err := g.db.Create(&models.LoginRecord{
+		UserID:    userID,
+		Account:   account,
+		IP:        ip,
+		UserAgent: userAgent,
+		Status:    status,
+	}).Error; err != nil {
+		log.Printf("Login guard: failed to record %s login of %s: %v", status, account, err)
+	}
+}
+
+// countFailure adds a failure to the key and returns the lockout if this
+// failure locked it.
+func (g *LoginGuard) countFailure(kind, value string, limit int) (*models.LoginLockout, error) {
+	var locked *models.LoginLockout
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		// A new key starts out as if its last failure were now, which strict
+		// MySQL also needs instead of a zero time
+		now := g.clock.Now()
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginLockout{
+			Kind:          kind,
+			Value:         value,
+			LastFailureAt: now,
+		}).Error; err != nil {
+			return err
+		}
+
+		var lockout models.LoginLockout
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kind = ? AND value = ?", kind, value).First(&lockout).Error; err != nil {
+			return err
+		}
+
+		if now.Sub(lockout.LastFailureAt) > lockoutDecay {
+			lockout.Lockouts = 0
+		}
+		if now.Sub(lockout.LastFailureAt) > failureWindow {
+			lockout.Failures = 0
+		}
+		lockout.Failures++
+		lockout.LastFailureAt = now
+
+		if lockout.Failures >= limit {
+			until := now.Add(lockoutDuration(lockout.Lockouts))
+			lockout.Lockouts++
+			lockout.Failures = 0
+			lockout.LockedUntil = &until
+			locked = &lockout
+		}
+
+		return tx.Model(&lockout).Select("failures", "lockouts", "locked_until", "last_failure_at").
+			Updates(&lockout).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return locked, nil
+}
+
+// lockoutDuration doubles with every earlier lockout, up to maxLockout.
+func lockoutDuration(previous int) time.Duration {
+	duration := baseLockout
+	for i := 0; i < previous && duration < maxLockout; i++ {
+		duration *= 2
+	}
+	if duration > maxLockout {
+		duration = maxLockout
+	}
+	return duration
+}
+
+// alertIP warns admins about an IP locked out for failing across accounts.
+func (g *LoginGuard) alertIP(lockout *models.LoginLockout) {
+	var accounts int64
+	if err := g.db.Model(&models.LoginRecord{}).
+		Where("ip = ? AND status = ? AND created_at > ?", lockout.Value, models.LoginFailed, g.clock.Now().Add(-failureWindow)).
+		Distinct("account").Count(&accounts).Error; err != nil {
+		log.Printf("Login guard: failed to inspect IP %s: %v", lockout.Value, err)
+	}
+
+	g.alert(lockout, "Possible password guessing attack",
+		fmt.Sprintf("IP %s was locked out until %s after %d failed logins against %d accounts",
+			lockout.Value, lockout.LockedUntil.Format("15:04"), ipFailureLimit, accounts),
+		map[string]interface{}{"accounts": accounts})
+}
+
+// alertAccount warns admins when an account was locked out by failures from
+// several IPs. Failures from a single IP are most likely a forgotten
+// password and aren't reported.
+func (g *LoginGuard) alertAccount(lockout *models.LoginLockout) {
+	var ips int64
+	if err := g.db.Model(&models.LoginRecord{}).
+		Where("account = ? AND status = ? AND created_at > ?", lockout.Value, models.LoginFailed, g.clock.Now().Add(-failureWindow)).
+		Distinct("ip").Count(&ips).Error; err != nil {
+		log.Printf("Login guard: failed to inspect account %s: %v", lockout.Value, err)
+		return
+	}
+	if ips < distributedAttackIPs {
+		return
+	}
+
+	g.alert(lockout, "Possible distributed attack on an account",
+		fmt.Sprintf("Account %s was locked out until %s after failed logins from %d IPs",
+			lockout.Value, lockout.LockedUntil.Format("15:04"), ips),
+		map[string]interface{}{"ips": ips})
+}
+
+func (g *LoginGuard) alert(lockout *models.LoginLockout, title, message string, data map[string]interface{}) {
+	log.Printf("Login guard: %s: %s", title, message)
+
+	data["lockout_id"] = lockout.ID
+	data["kind"] = lockout.Kind
+	data["value"] = lockout.Value
+	data["locked_until"] = lockout.LockedUntil
+	data["lockouts"] = lockout.Lockouts
+
+	var adminIDs []uint
+	if err := g.db.Model(&models.User{}).
+		Where("role = ? AND is_active = ?", models.RoleControlAdmin, true).
+		Pluck("id", &adminIDs).Error; err != nil {
+		log.Printf("Login guard: failed to load admins: %v", err)
+		return
+	}
+	for _, adminID := range adminIDs {
+		if _, err := g.notifications.Notify(adminID, SystemNotification, title, message, data); err != nil {
+			log.Printf("Login guard: failed to notify admin %d: %v", adminID, err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"testing"
+	"time"
+)
+
+func newTestLoginGuard(t *testing.T) (*LoginGuard, *fakeClock) {
+	t.Helper()
+
+	db := newTestDB(t)
+	hub := NewWebSocketHub()
+	go hub.Run()
+	clock := newFakeClock(time.Now())
+	return NewLoginGuard(db, NewNotificationService(db, hub), clock), clock
+}
+
+// failAccount fails the account's login the given number of times, each from
+// a different IP so only the account is counted towards a lockout.
+func failAccount(guard *LoginGuard, account string, times int) {
+	for i := 0; i < times; i++ {
+		guard.Failed(nil, account, fmt.Sprintf("10.0.0.%d", i+1), "test")
+	}
+}
+
+// lockedFor returns how long the account is locked out from now, 0 if it
+// isn't.
+func lockedFor(t *testing.T, guard *LoginGuard, clock *fakeClock, account string) time.Duration {
+	t.Helper()
+
+	err := guard.Check(account, "192.0.2.1")
+	if err == nil {
+		return 0
+	}
+	var lockout *LockoutError
+	if !errors.As(err, &lockout) {
+		t.Fatalf("Check: %v", err)
+	}
+	if !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("lockout error %v doesn't match ErrLoginLocked", err)
+	}
+	return lockout.Until.Sub(clock.Now())
+}
+
+func TestLoginGuardCountsFromFirstFailure(t *testing.T) {
+	guard, clock := newTestLoginGuard(t)
+
+	failAccount(guard, "alice", 1)
+
+	var lockout models.LoginLockout
+	if err := guard.db.Where("kind = ? AND value = ?", models.LockoutAccount, "alice").First(&lockout).Error; err != nil {
+		t.Fatalf("load lockout: %v", err)
+	}
+	if !lockout.LastFailureAt.Equal(clock.Now()) {
+		t.Errorf("last failure at %v, want %v", lockout.LastFailureAt, clock.Now())
+	}
+	if lockout.Failures != 1 {
+		t.Errorf("failures = %d, want 1", lockout.Failures)
+	}
+}
+
+func TestLoginGuardLockout(t *testing.T) {
+	tests := []struct {
+		name string
+		// steps alternate failing the account and letting time pass
+		steps []func(guard *LoginGuard, clock *fakeClock)
+		want  time.Duration
+	}{
+		{
+			name: "below the limit",
+			steps: []func(*LoginGuard, *fakeClock){
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit-1) },
+			},
+			want: 0,
+		},
+		{
+			name: "first lockout",
+			steps: []func(*LoginGuard, *fakeClock){
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit) },
+			},
+			want: baseLockout,
+		},
+		{
+			name: "failures outside the window are forgotten",
+			steps: []func(*LoginGuard, *fakeClock){
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit-1) },
+				func(_ *LoginGuard, c *fakeClock) { c.Advance(failureWindow + time.Minute) },
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", 1) },
+			},
+			want: 0,
+		},
+		{
+			name: "lockout expires",
+			steps: []func(*LoginGuard, *fakeClock){
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit) },
+				func(_ *LoginGuard, c *fakeClock) { c.Advance(baseLockout + time.Second) },
+			},
+			want: 0,
+		},
+		{
+			name: "repeat lockouts back off",
+			steps: []func(*LoginGuard, *fakeClock){
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit) },
+				func(_ *LoginGuard, c *fakeClock) { c.Advance(baseLockout + time.Second) },
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit) },
+				func(_ *LoginGuard, c *fakeClock) { c.Advance(2*baseLockout + time.Second) },
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit) },
+			},
+			want: 4 * baseLockout,
+		},
+		{
+			name: "backoff decays after a quiet period",
+			steps: []func(*LoginGuard, *fakeClock){
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit) },
+				func(_ *LoginGuard, c *fakeClock) { c.Advance(baseLockout + time.Second) },
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit) },
+				func(_ *LoginGuard, c *fakeClock) { c.Advance(lockoutDecay + time.Minute) },
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit) },
+			},
+			want: baseLockout,
+		},
+		{
+			name: "success forgets the account's failures",
+			steps: []func(*LoginGuard, *fakeClock){
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", accountFailureLimit-1) },
+				func(g *LoginGuard, _ *fakeClock) { g.Succeeded(1, "alice", "10.0.0.1", "test") },
+				func(g *LoginGuard, _ *fakeClock) { failAccount(g, "alice", 1) },
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, clock := newTestLoginGuard(t)
+			for _, step := range tt.steps {
+				step(guard, clock)
+			}
+			if got := lockedFor(t, guard, clock, "alice"); got != tt.want {
+				t.Errorf("locked for %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginGuardLocksOutIP(t *testing.T) {
+	guard, clock := newTestLoginGuard(t)
+
+	for i := 0; i < ipFailureLimit; i++ {
+		guard.Failed(nil, fmt.Sprintf("user%d", i), "203.0.113.7", "test")
+	}
+
+	if err := guard.Check("someone-else", "203.0.113.7"); !errors.Is(err, ErrLoginLocked) {
+		t.Errorf("Check from the IP = %v, want a lockout", err)
+	}
+	if err := guard.Check("someone-else", "203.0.113.8"); err != nil {
+		t.Errorf("Check from another IP = %v, want nil", err)
+	}
+
+	clock.Advance(baseLockout + time.Second)
+	if err := guard.Check("someone-else", "203.0.113.7"); err != nil {
+		t.Errorf("Check after the lockout = %v, want nil", err)
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"fmt"
+	"interview-system/models"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens a migrated SQLite database of its own for the test. Writes
+// take the database lock when their transaction begins, which stands in for
+// the row locks MySQL would take.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate",
+		filepath.Join(t.TempDir(), "test.db"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Company{},
+		&models.User{},
+		&models.LoginRecord{},
+		&models.LoginLockout{},
+		&models.RefreshToken{},
+		&models.Invitation{},
+		&models.Position{},
+		&models.PositionInterviewer{},
+		&models.PositionRound{},
+		&models.CandidatePosition{},
+		&models.Interview{},
+		&models.GroupInterview{},
+		&models.GroupInterviewInvitation{},
+		&models.QueueEntry{},
+		&models.QueueOptimization{},
+		&models.Event{},
+		&models.Notification{},
+		&models.ApplicationTransition{},
+		&models.ScorecardTemplate{},
+		&models.ScorecardCriterion{},
+		&models.Evaluation{},
+		&models.EvaluationScore{},
+	); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// fakeClock is a Clock tests move by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
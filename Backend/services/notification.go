@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"interview-system/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationService stores notifications for users and pushes them over
+// the WebSocket, so users who were offline can still catch up.
+type NotificationService struct {
+	db    *gorm.DB
+	wsHub *WebSocketHub
+}
+
+func NewNotificationService(db *gorm.DB, wsHub *WebSocketHub) *NotificationService {
+	return &NotificationService{db: db, wsHub: wsHub}
+}
+
+// Notify stores the notification and sends it to the user's live connections.
+// The live message carries the stored notification's ID.
+func (s *NotificationService) Notify(userID uint, msgType MessageType, title, message string, data map[string]interface{}) (*models.Notification, error) {
+	notification := models.Notification{
+		UserID:  userID,
+		Type:    string(msgType),
+		Title:   title,
+		Message: message,
+		Data:    data,
+	}
+	if err := s.db.Create(&notification).Error; err != nil {
+		return nil, err
+	}
+
+	payload := make(map[string]interface{}, len(data)+3)
+	for key, value := range data {
+		payload[key] = value
+	}
+	payload["notification_id"] = notification.ID
+	payload["title"] = title
+	payload["message"] = message
+
+	s.wsHub.BroadcastToUser(userID, Message{
+		Type:      msgType,
+		Data:      payload,
+		Timestamp: notification.CreatedAt,
+	})
+	return &notification, nil
+}
+
+// List returns the user's latest notifications and how many are unread.
+func (s *NotificationService) List(userID uint, unreadOnly bool, limit int) ([]models.Notification, int64, error) {
+	query := s.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var unread int64
+	if err := s.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Count(&unread).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, unread, nil
+}
+
+// MarkRead marks one of the user's notifications as read, or all of them when
+// notificationID is 0.
+func (s *NotificationService) MarkRead(userID, notificationID uint) error {
+	query := s.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if notificationID != 0 {
+		var count int64
+		if err := s.db.Model(&models.Notification{}).
+			Where("id = ? AND user_id = ?", notificationID, userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrNotificationNotFound
+		}
+		query = query.Where("id = ?", notificationID)
+	}
+	return query.Update("read_at", time.Now()).Error
+}
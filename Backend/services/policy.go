@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"interview-system/models"
+	"time"
+)
+
+// priorityCutoff is how long before the end of an event candidates stop being
+// able to mark new positions as high priority.
+const priorityCutoff = 30 * time.Minute
+
+var (
+	ErrActivityNotStarted = errors.New("the recruitment event has not started yet")
+	ErrActivityEnded      = errors.New("the recruitment event has ended")
+	ErrJoinClosed         = errors.New("queues are closed to new candidates in the last 10 minutes of the event")
+	ErrPriorityClosed     = errors.New("cannot set high priority within 30 minutes of activity end")
+)
+
+type CandidateAction string
+
+const (
+	ActionJoinQueue   CandidateAction = "join_queue"
+	ActionSetPriority CandidateAction = "set_priority"
+	ActionDelay       CandidateAction = "delay"
+	ActionJumpAhead   CandidateAction = "jump_ahead"
+	ActionOptimize    CandidateAction = "optimize"
+)
+
+// ActivityPolicy decides whether a candidate may change their queues given
+// where the event is in its schedule. Every candidate queue mutation goes
+// through Allow so the rules live in one place.
+type ActivityPolicy struct {
+	clock Clock
+}
+
+func NewActivityPolicy(clock Clock) *ActivityPolicy {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &ActivityPolicy{clock: clock}
+}
+
+// Allow returns nil if the action is permitted right now, or one of the
+// ErrActivity*/Err*Closed errors explaining why not.
+func (p *ActivityPolicy) Allow(event *models.Event, action CandidateAction) error {
+	now := p.clock.Now()
+
+	switch PhaseAt(event, now) {
+	case models.EventPending:
+		return ErrActivityNotStarted
+	case models.EventCompleted:
+		return ErrActivityEnded
+	case models.EventClosing, models.EventFinalCall:
+		if action == ActionJoinQueue {
+			return ErrJoinClosed
+		}
+	}
+
+	if action == ActionSetPriority && event.EndTime.Sub(now) < priorityCutoff {
+		return ErrPriorityClosed
+	}
+
+	return nil
+}
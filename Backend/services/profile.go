@@ -0,0 +1,231 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrResumeNotPDF        = errors.New("resume must be a PDF file")
+	ErrResumeTooLarge      = errors.New("resume is too large")
+	ErrNoResume            = errors.New("no resume uploaded")
+	ErrInvalidProfile      = errors.New("invalid profile")
+	ErrCandidateNotVisible = errors.New("candidate has not queued for any of your positions")
+)
+
+// pdfMagic starts every PDF file.
+var pdfMagic = []byte("%PDF-")
+
+type ProfileInput struct {
+	Education         string   `json:"education"`
+	Skills            []string `json:"skills"`
+	YearsOfExperience int      `json:"years_of_experience"`
+	Links             []string `json:"links"`
+}
+
+// ProfileService manages candidate profiles and resumes. Interviewers can see
+// a candidate's profile once the candidate queues for one of their positions.
+type ProfileService struct {
+	db            *gorm.DB
+	store         BlobStore
+	maxResumeSize int64
+}
+
+func NewProfileService(db *gorm.DB, store BlobStore, maxResumeSize int64) *ProfileService {
+	return &ProfileService{db: db, store: store, maxResumeSize: maxResumeSize}
+}
+
+// Get returns the candidate's profile, an empty one if they haven't filled it in.
+func (s *ProfileService) Get(userID uint) (*models.CandidateProfile, error) {
+	var profile models.CandidateProfile
+	err := s.db.Where("user_id = ?", userID).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.CandidateProfile{UserID: userID, Skills: []string{}, Links: []string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Update replaces the candidate's profile details, keeping their resume.
+func (s *ProfileService) Update(userID uint, input ProfileInput) (*models.CandidateProfile, error) {
+	if input.YearsOfExperience < 0 || input.YearsOfExperience > 70 {
+		return nil, fmt.Errorf("%w: years of experience must be between 0 and 70", ErrInvalidProfile)
+	}
+
+	skills := make([]string, 0, len(input.Skills))
+	seen := make(map[string]bool)
+	for _, skill := range input.Skills {
+		skill = strings.TrimSpace(skill)
+		if skill == "" || seen[strings.ToLower(skill)] {
+			continue
+		}
+		seen[strings.ToLower(skill)] = true
+		skills = append(skills, skill)
+	}
+
+	links := make([]string, 0, len(input.Links))
+	for _, link := range input.Links {
+		link = strings.TrimSpace(link)
+		if link == "" {
+			continue
+		}
+		parsed, err := url.Parse(link)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("%w: %q is not an http(s) link", ErrInvalidProfile, link)
+		}
+		links = append(links, link)
+	}
+
+	profile, err := s.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Education = strings.TrimSpace(input.Education)
+	profile.Skills = skills
+	profile.YearsOfExperience = input.YearsOfExperience
+	profile.Links = links
+
+	if err := s.db.Save(profile).Error; err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// UploadResume stores a PDF resume for the candidate, replacing any earlier one.
+func (s *ProfileService) UploadResume(userID uint, fileName string, size int64, r io.Reader) (*models.CandidateProfile, error) {
+	if size > s.maxResumeSize {
+		return nil, ErrResumeTooLarge
+	}
+
+	header := make([]byte, len(pdfMagic))
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header, pdfMagic) {
+		return nil, ErrResumeNotPDF
+	}
+
+	// The size came from the client, so cap what is actually stored as well
+	body := &limitedReader{r: io.MultiReader(bytes.NewReader(header), r), limit: s.maxResumeSize}
+	key := fmt.Sprintf("%d/%d.pdf", userID, time.Now().UnixNano())
+	if err := s.store.Put(key, body); err != nil {
+		if errors.Is(err, ErrResumeTooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to store resume: %w", err)
+	}
+
+	profile, err := s.Get(userID)
+	if err != nil {
+		s.store.Delete(key)
+		return nil, err
+	}
+	previous := profile.ResumeKey
+
+	now := time.Now()
+	profile.ResumeKey = key
+	profile.ResumeFileName = fileName
+	profile.ResumeSize = body.read
+	profile.ResumeUploadedAt = &now
+	if err := s.db.Save(profile).Error; err != nil {
+		s.store.Delete(key)
+		return nil, err
+	}
+
+	if previous != "" {
+		s.store.Delete(previous)
+	}
+	return profile, nil
+}
+
+// OpenResume returns the candidate's resume. The caller closes the reader.
+func (s *ProfileService) OpenResume(userID uint) (io.ReadCloser, *models.CandidateProfile, error) {
+	profile, err := s.Get(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !profile.HasResume() {
+		return nil, nil, ErrNoResume
+	}
+
+	file, err := s.store.Open(profile.ResumeKey)
+	if errors.Is(err, ErrBlobNotFound) {
+		return nil, nil, ErrNoResume
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, profile, nil
+}
+
+// CandidateProfile returns a candidate's profile to an interviewer who may see it.
+func (s *ProfileService) CandidateProfile(interviewerID, candidateID uint) (*models.CandidateProfile, error) {
+	if err := s.checkVisible(interviewerID, candidateID); err != nil {
+		return nil, err
+	}
+	return s.Get(candidateID)
+}
+
+// CandidateResume opens a candidate's resume for an interviewer who may see it.
+func (s *ProfileService) CandidateResume(interviewerID, candidateID uint) (io.ReadCloser, *models.CandidateProfile, error) {
+	if err := s.checkVisible(interviewerID, candidateID); err != nil {
+		return nil, nil, err
+	}
+	return s.OpenResume(candidateID)
+}
+
+// checkVisible allows interviewers to see the candidates of their own
+// positions' queues and interviews. Interviewers without an assigned position
+// only see the candidates they have interviewed.
+func (s *ProfileService) checkVisible(interviewerID, candidateID uint) error {
+	var count int64
+	if err := s.db.Model(&models.Interview{}).
+		Where("interviewer_id = ? AND candidate_id = ?", interviewerID, candidateID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	positionIDs, err := InterviewerPositions(s.db, interviewerID, 0)
+	if errors.Is(err, ErrNoAssignedPosition) {
+		return ErrCandidateNotVisible
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Model(&models.QueueEntry{}).
+		Where("candidate_id = ? AND position_id IN ?", candidateID, positionIDs).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrCandidateNotVisible
+	}
+	return nil
+}
+
+// limitedReader fails with ErrResumeTooLarge instead of silently truncating.
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n, ErrResumeTooLarge
+	}
+	return n, err
+}
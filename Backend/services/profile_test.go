@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+	"interview-system/models"
+	"testing"
+	"time"
+)
+
+func TestCandidateVisibility(t *testing.T) {
+	s := newTestQueueService(t)
+	event, positionID, users := seedQueue(t, s, 3)
+	candidateID, assignedID, unassignedID := users[0], users[1], users[2]
+
+	addWaiting(t, s, models.QueueEntry{
+		EventID:     event.ID,
+		CandidateID: candidateID,
+		PositionID:  positionID,
+		Priority:    models.PriorityRegular,
+		JoinTime:    time.Now(),
+	})
+	if err := s.db.Create(&models.PositionInterviewer{PositionID: positionID, InterviewerID: assignedID}).Error; err != nil {
+		t.Fatalf("assign interviewer: %v", err)
+	}
+
+	profiles := NewProfileService(s.db, NewLocalBlobStore(t.TempDir()), 1<<20)
+
+	tests := []struct {
+		name          string
+		interviewerID uint
+		want          error
+	}{
+		{"assigned to the candidate's position", assignedID, nil},
+		{"no position assigned", unassignedID, ErrCandidateNotVisible},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := profiles.checkVisible(tt.interviewerID, candidateID); !errors.Is(err, tt.want) {
+				t.Errorf("checkVisible = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -4,122 +4,209 @@ import (
 	"errors"
 	"fmt"
 	"interview-system/models"
+	"log"
 	"sort"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// closedQueueStatuses are the statuses of entries that no longer hold a place.
+var closedQueueStatuses = []string{"completed", "left", "expired", "no_show"}
+
+var (
+	ErrAlreadyInQueue = errors.New("already in queue for this position")
+	ErrNotInQueue     = errors.New("not in queue for this position")
 )
 
 type QueueService struct {
-	db    *gorm.DB
-	wsHub *WebSocketHub
+	db           *gorm.DB
+	wsHub        *WebSocketHub
+	events       *EventService
+	policy       *ActivityPolicy
+	cache        *QueueCache
+	schedule     *ScheduleEngine
+	admission    *Admission
+	applications *ApplicationService
 }
 
 type QueueInfo struct {
 	Position          models.Position   `json:"position"`
+	Round             int               `json:"round"`
 	QueuePosition     int               `json:"queue_position"`
 	TotalInQueue      int               `json:"total_in_queue"`
 	IsHighPriority    bool              `json:"is_high_priority"`
+	Priority          int               `json:"priority"`
 	EstimatedWaitTime int               `json:"estimated_wait_time"`
 	Status            string            `json:"status"`
 	CanSetPriority    bool              `json:"can_set_priority"`
 	JoinedAt          time.Time         `json:"joined_at"`
 }
 
-func NewQueueService(db *gorm.DB, wsHub *WebSocketHub) *QueueService {
+func NewQueueService(db *gorm.DB, wsHub *WebSocketHub, events *EventService, policy *ActivityPolicy, cache *QueueCache, schedule *ScheduleEngine, admission *Admission, applications *ApplicationService) *QueueService {
 	return &QueueService{
-		db:    db,
-		wsHub: wsHub,
+		db:           db,
+		wsHub:        wsHub,
+		events:       events,
+		policy:       policy,
+		cache:        cache,
+		schedule:     schedule,
+		admission:    admission,
+		applications: applications,
 	}
 }
 
 func (s *QueueService) JoinQueue(candidateID uint, positionID uint) error {
-	var existing models.QueueEntry
-	if err := s.db.Where("candidate_id = ? AND position_id = ? AND status NOT IN (?)",
-		candidateID, positionID, []string{"completed", "left"}).First(&existing).Error; err == nil {
-		return errors.New("already in queue for this position")
+	event, err := s.events.ForPosition(positionID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.policy.Allow(event, ActionJoinQueue); err != nil {
+		return err
 	}
 
-	var activeCount int64
-	s.db.Model(&models.QueueEntry{}).Where("candidate_id = ? AND is_active = ? AND status NOT IN (?)",
-		candidateID, true, []string{"completed", "left"}).Count(&activeCount)
+	var entry models.QueueEntry
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCandidate(tx, candidateID); err != nil {
+			return err
+		}
 
-	var activity models.ActivityControl
-	s.db.First(&activity)
+		var existing int64
+		if err := tx.Model(&models.QueueEntry{}).Where("event_id = ? AND candidate_id = ? AND position_id = ? AND status NOT IN ?",
+			event.ID, candidateID, positionID, closedQueueStatuses).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyInQueue
+		}
 
-	isActive := activeCount < int64(activity.ActiveQueueLimit)
+		var activeCount int64
+		if err := tx.Model(&models.QueueEntry{}).Where("event_id = ? AND candidate_id = ? AND is_active = ? AND status NOT IN ?",
+			event.ID, candidateID, true, closedQueueStatuses).Count(&activeCount).Error; err != nil {
+			return err
+		}
 
-	entry := models.QueueEntry{
-		CandidateID:    candidateID,
-		PositionID:     positionID,
-		JoinTime:       time.Now(),
-		IsHighPriority: false,
-		IsActive:       isActive,
-		Status:         "waiting",
-		DelayUsed:      0,
-	}
+		isActive := activeCount < int64(event.ActiveQueueLimit)
+
+		entry = models.QueueEntry{
+			EventID:        event.ID,
+			CandidateID:    candidateID,
+			PositionID:     positionID,
+			Round:          1,
+			JoinTime:       time.Now(),
+			IsHighPriority: false,
+			Priority:       models.PriorityRegular,
+			IsActive:       isActive,
+			Status:         "waiting",
+			DelayUsed:      0,
+			OpenKey:        models.QueueOpenKey(event.ID, candidateID, positionID),
+		}
 
-	if err := s.db.Create(&entry).Error; err != nil {
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		return reorderQueue(tx, event.ID, positionID)
+	})
+	if err != nil {
 		return err
 	}
+	s.cache.Add(&entry)
+	s.reschedule(event.ID, candidateID)
+	if err := s.applications.Ensure(candidateID, positionID); err != nil {
+		log.Printf("Failed to open application of candidate %d for position %d: %v", candidateID, positionID, err)
+	}
+
+	s.broadcastQueueUpdate(positionID)
+
+	return nil
+}
 
-	s.updateQueuePositions(positionID)
+// LeaveQueue takes the candidate out of the position queue and closes the gap
+// they leave behind.
+func (s *QueueService) LeaveQueue(candidateID uint, positionID uint) error {
+	var entry models.QueueEntry
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCandidate(tx, candidateID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("candidate_id = ? AND position_id = ? AND status = ?",
+			candidateID, positionID, "waiting").First(&entry).Error; err != nil {
+			return ErrNotInQueue
+		}
+		if err := tx.Model(&entry).Updates(models.CloseQueueEntry("left")).Error; err != nil {
+			return err
+		}
 
-	// Check and resolve conflicts after joining new queue
-	hasConflicts, _ := s.ResolveConflicts(candidateID)
-	if hasConflicts {
-		// Conflicts were detected and resolved
-		s.broadcastQueueUpdate(positionID)
-	} else {
-		s.broadcastQueueUpdate(positionID)
+		return reorderQueue(tx, entry.EventID, positionID)
+	})
+	if err != nil {
+		return err
 	}
+	s.cache.Remove(entry.EventID, positionID, candidateID)
+	s.reschedule(entry.EventID, candidateID)
 
+	s.broadcastQueueUpdate(positionID)
 	return nil
 }
 
 func (s *QueueService) SetHighPriority(candidateID uint, positionID uint) error {
-	var activity models.ActivityControl
-	s.db.First(&activity)
-
-	if time.Until(activity.EndTime) < 30*time.Minute {
-		return errors.New("cannot set high priority within 30 minutes of activity end")
-	}
+	var entry models.QueueEntry
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCandidate(tx, candidateID); err != nil {
+			return err
+		}
 
-	var usedCount int64
-	s.db.Model(&models.QueueEntry{}).Where("candidate_id = ? AND is_high_priority = ? AND status NOT IN (?)",
-		candidateID, true, []string{"completed", "left"}).Count(&usedCount)
+		if err := tx.Where("candidate_id = ? AND position_id = ? AND status = ?",
+			candidateID, positionID, "waiting").First(&entry).Error; err != nil {
+			return ErrNotInQueue
+		}
 
-	if usedCount >= int64(activity.HighPriorityQuota) {
-		return errors.New("high priority quota exceeded")
-	}
+		event, err := s.events.Resolve(entry.EventID)
+		if err != nil {
+			return err
+		}
 
-	var entry models.QueueEntry
-	if err := s.db.Where("candidate_id = ? AND position_id = ? AND status = ?",
-		candidateID, positionID, "waiting").First(&entry).Error; err != nil {
-		return errors.New("not in queue for this position")
-	}
+		if err := s.policy.Allow(event, ActionSetPriority); err != nil {
+			return err
+		}
 
-	if entry.IsHighPriority {
-		return errors.New("already set as high priority")
-	}
+		var usedCount int64
+		tx.Model(&models.QueueEntry{}).Where("event_id = ? AND candidate_id = ? AND is_high_priority = ? AND status NOT IN ?",
+			entry.EventID, candidateID, true, closedQueueStatuses).Count(&usedCount)
 
-	now := time.Now()
-	entry.IsHighPriority = true
-	entry.PrioritySetTime = &now
-	entry.IsActive = true
+		if usedCount >= int64(event.HighPriorityQuota) {
+			return errors.New("high priority quota exceeded")
+		}
 
-	if err := s.db.Save(&entry).Error; err != nil {
-		return err
-	}
+		if entry.IsHighPriority {
+			return errors.New("already set as high priority")
+		}
 
-	s.updateQueuePositions(positionID)
+		now := time.Now()
+		entry.IsHighPriority = true
+		entry.Priority = models.PriorityHigh
+		entry.PrioritySetTime = &now
+		entry.IsActive = true
+		if err := tx.Model(&entry).Updates(map[string]interface{}{
+			"is_high_priority":  true,
+			"priority":          models.PriorityHigh,
+			"priority_set_time": now,
+			"is_active":         true,
+		}).Error; err != nil {
+			return err
+		}
 
-	// Only resolve conflicts if no optimization is available
-	canOptimize, _ := s.CheckQueueOptimization(candidateID)
-	if !canOptimize {
-		// Check and resolve conflicts after priority change
-		s.ResolveConflicts(candidateID)
+		return reorderQueue(tx, entry.EventID, positionID)
+	})
+	if err != nil {
+		return err
 	}
+	s.cache.Add(&entry)
+	s.reschedule(entry.EventID, candidateID)
 
 	s.broadcastQueueUpdate(positionID)
 
@@ -129,29 +216,31 @@ func (s *QueueService) SetHighPriority(candidateID uint, positionID uint) error
 func (s *QueueService) GetCandidateQueues(candidateID uint) ([]QueueInfo, error) {
 	var entries []models.QueueEntry
 	if err := s.db.Preload("Position").Preload("Position.Company").
-		Where("candidate_id = ? AND status NOT IN (?)", candidateID, []string{"completed", "left"}).
+		Where("candidate_id = ? AND status NOT IN ?", candidateID, closedQueueStatuses).
 		Find(&entries).Error; err != nil {
 		return nil, err
 	}
 
-	var activity models.ActivityControl
-	s.db.First(&activity)
-
-	canSetPriority := time.Until(activity.EndTime) >= 30*time.Minute
-
 	queues := make([]QueueInfo, len(entries))
 	for i, entry := range entries {
-		totalInQueue := s.getQueueLength(entry.PositionID)
-		queuePos := s.getQueuePosition(entry.PositionID, candidateID)
+		event, err := s.events.Resolve(entry.EventID)
+		if err != nil {
+			return nil, err
+		}
+		canSetPriority := s.policy.Allow(event, ActionSetPriority) == nil
+
+		totalInQueue := s.getQueueLength(entry.EventID, entry.PositionID, entry.Round)
+		queuePos := s.getQueuePosition(entry.EventID, entry.PositionID, entry.Round, candidateID)
 
-		// Calculate actual wait time - use smart optimization if available
-		actualWaitTime := s.calculateSmartWaitTime(entry.PositionID, candidateID, activity.AverageInterviewTime)
+		actualWaitTime := s.projectedWait(&entry, event.AverageInterviewTime)
 
 		queues[i] = QueueInfo{
 			Position:          entry.Position,
+			Round:             entryRound(entry.Round),
 			QueuePosition:     queuePos,
 			TotalInQueue:      totalInQueue,
 			IsHighPriority:    entry.IsHighPriority,
+			Priority:          entry.Priority,
 			EstimatedWaitTime: actualWaitTime,
 			Status:            entry.Status,
 			CanSetPriority:    canSetPriority && !entry.IsHighPriority,
@@ -162,29 +251,104 @@ func (s *QueueService) GetCandidateQueues(candidateID uint) ([]QueueInfo, error)
 	return queues, nil
 }
 
-func (s *QueueService) updateQueuePositions(positionID uint) {
+func (s *QueueService) updateQueuePositions(eventID, positionID uint) {
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return reorderQueue(tx, eventID, positionID)
+	}); err != nil {
+		log.Printf("Failed to update queue positions for position %d: %v", positionID, err)
+	}
+}
+
+// queueRef names one event's queue for a position. A position taking part in
+// several events has a separate queue in each.
+type queueRef struct {
+	eventID    uint
+	positionID uint
+}
+
+// reorderQueue renumbers the waiting entries of each round of the event's
+// queue for the position 1..n. The entries are locked first so concurrent
+// reorders of the same queue run one at a time.
+func reorderQueue(tx *gorm.DB, eventID, positionID uint) error {
 	var entries []models.QueueEntry
-	s.db.Where("position_id = ? AND status = ?", positionID, "waiting").
-		Order("is_high_priority DESC, priority_set_time ASC, join_time ASC").
-		Find(&entries)
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("event_id = ? AND position_id = ? AND status = ?", eventID, positionID, "waiting").
+		Order(models.QueueOrder).
+		Find(&entries).Error; err != nil {
+		return err
+	}
 
-	for i, entry := range entries {
-		entry.QueuePosition = i + 1
-		s.db.Save(&entry)
+	places := make(map[int]int)
+	for _, entry := range entries {
+		round := entryRound(entry.Round)
+		places[round]++
+		if entry.QueuePosition == places[round] {
+			continue
+		}
+		if err := tx.Model(&entry).UpdateColumn("queue_position", places[round]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reorderQueues reorders several queues in ascending event and position
+// order, so transactions touching the same queues never lock them in opposite
+// orders.
+func reorderQueues(tx *gorm.DB, queues ...queueRef) error {
+	sorted := append([]queueRef(nil), queues...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].eventID != sorted[j].eventID {
+			return sorted[i].eventID < sorted[j].eventID
+		}
+		return sorted[i].positionID < sorted[j].positionID
+	})
+
+	for i, queue := range sorted {
+		if i > 0 && queue == sorted[i-1] {
+			continue
+		}
+		if err := reorderQueue(tx, queue.eventID, queue.positionID); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (s *QueueService) getQueueLength(positionID uint) int {
+// lockCandidate takes a row lock on the candidate, serialising their queue
+// mutations so limits such as the high priority quota can't be raced past.
+func lockCandidate(tx *gorm.DB, candidateID uint) error {
+	var user models.User
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, candidateID).Error
+}
+
+// getQueueLength counts the waiting entries of one round of the event's queue
+// for the position. The cache only holds first round queues.
+func (s *QueueService) getQueueLength(eventID, positionID uint, round int) int {
+	round = entryRound(round)
+	if round == 1 {
+		if length, ok := s.cache.Length(eventID, positionID); ok {
+			return length
+		}
+	}
+
 	var count int64
-	s.db.Model(&models.QueueEntry{}).Where("position_id = ? AND status = ?",
-		positionID, "waiting").Count(&count)
+	s.db.Model(&models.QueueEntry{}).Where("event_id = ? AND position_id = ? AND round = ? AND status = ?",
+		eventID, positionID, round, "waiting").Count(&count)
 	return int(count)
 }
 
-func (s *QueueService) getQueuePosition(positionID uint, candidateID uint) int {
+func (s *QueueService) getQueuePosition(eventID, positionID uint, round int, candidateID uint) int {
+	round = entryRound(round)
+	if round == 1 {
+		if rank, ok := s.cache.Rank(eventID, positionID, candidateID); ok {
+			return rank
+		}
+	}
+
 	var entries []models.QueueEntry
-	s.db.Where("position_id = ? AND status = ?", positionID, "waiting").
-		Order("is_high_priority DESC, priority_set_time ASC, join_time ASC").
+	s.db.Where("event_id = ? AND position_id = ? AND round = ? AND status = ?", eventID, positionID, round, "waiting").
+		Order(models.QueueOrder).
 		Find(&entries)
 
 	for i, entry := range entries {
@@ -216,188 +380,28 @@ func (s *QueueService) estimateWaitTimeWithDelay(position int, avgInterviewTime
 	return baseWaitTime + delayMinutes
 }
 
-// calculateSmartWaitTime calculates wait time with queue optimization prioritized over conflict resolution
-func (s *QueueService) calculateSmartWaitTime(positionID uint, candidateID uint, avgInterviewTime int) int {
-	// Get all queues for this candidate
-	var candidateQueues []models.QueueEntry
-	s.db.Where("candidate_id = ? AND status = ?", candidateID, "waiting").
-		Preload("Position").Find(&candidateQueues)
-
-	// If candidate has only one queue, use simple calculation
-	if len(candidateQueues) <= 1 {
-		queuePos := s.getQueuePosition(positionID, candidateID)
-		if queuePos <= 0 {
-			return 0
-		}
-		return (queuePos - 1) * avgInterviewTime
-	}
-
-	// Multiple queues - first check for optimization opportunity
-	var activity models.ActivityControl
-	s.db.First(&activity)
-
-	// Get current position's details
-	var currentEntry models.QueueEntry
-	for _, entry := range candidateQueues {
-		if entry.PositionID == positionID {
-			currentEntry = entry
-			break
-		}
-	}
-
-	// Calculate base wait times for all positions
-	baseWaitTimes := make(map[uint]int)
-	for _, entry := range candidateQueues {
-		queuePos := s.getQueuePosition(entry.PositionID, candidateID)
-		baseWaitTimes[entry.PositionID] = (queuePos - 1) * avgInterviewTime
-	}
-
-	// Check if this position is eligible for optimization
-	// Case 1: This is a regular position that's faster than priority positions
-	if !currentEntry.IsHighPriority {
-		// Check if any priority position has longer wait
-		for _, entry := range candidateQueues {
-			if entry.IsHighPriority {
-				priorityWait := baseWaitTimes[entry.PositionID]
-				currentWait := baseWaitTimes[positionID]
-
-				// If this regular position is significantly faster (can complete before priority starts)
-				if currentWait + avgInterviewTime + activity.BufferTime <= priorityWait {
-					// Check if this optimized position conflicts with other simultaneous interviews
-					// Count how many other positions also have 0 wait time
-					simultaneousCount := 0
-					for _, otherEntry := range candidateQueues {
-						if otherEntry.PositionID != positionID {
-							otherWait := baseWaitTimes[otherEntry.PositionID]
-							if otherWait == currentWait { // Same wait time = potential conflict
-								simultaneousCount++
-							}
-						}
-					}
-
-					// If there are simultaneous interviews, apply conflict resolution
-					if simultaneousCount > 0 {
-						return s.calculateActualWaitTime(positionID, candidateID, avgInterviewTime)
-					}
-
-					// Return the actual base wait time for this position
-					return currentWait
-				}
-			}
+// projectedWait is the entry's wait in minutes according to the schedule
+// projection, or a plain queue position estimate until it has been projected.
+func (s *QueueService) projectedWait(entry *models.QueueEntry, avgInterviewTime int) int {
+	if entry.EstimatedTime != nil {
+		if wait := time.Until(*entry.EstimatedTime); wait > 0 {
+			return int(wait.Minutes())
 		}
+		return 0
 	}
-
-	// Case 2: This is a priority position - check for conflicts first
-	if currentEntry.IsHighPriority {
-		currentWait := baseWaitTimes[positionID]
-
-		// Check if this priority position conflicts with other simultaneous interviews
-		simultaneousCount := 0
-		for _, otherEntry := range candidateQueues {
-			if otherEntry.PositionID != positionID {
-				otherWait := baseWaitTimes[otherEntry.PositionID]
-				if otherWait == currentWait { // Same wait time = potential conflict
-					simultaneousCount++
-				}
-			}
-		}
-
-		// If there are simultaneous interviews, apply conflict resolution
-		if simultaneousCount > 0 {
-			return s.calculateActualWaitTime(positionID, candidateID, avgInterviewTime)
-		}
-
-		return baseWaitTimes[positionID]
-	}
-
-	// No optimization available, fall back to conflict resolution
-	return s.calculateActualWaitTime(positionID, candidateID, avgInterviewTime)
+	return s.estimateWaitTime(s.getQueuePosition(entry.EventID, entry.PositionID, entry.Round, entry.CandidateID), avgInterviewTime)
 }
 
-func (s *QueueService) calculateActualWaitTime(positionID uint, candidateID uint, avgInterviewTime int) int {
-	fmt.Printf("DEBUG calculateActualWaitTime: positionID=%d, candidateID=%d\n", positionID, candidateID)
-
-	// First, run conflict resolution to ensure queue positions are up to date
-	hasConflicts, messages := s.ResolveConflicts(candidateID)
-	fmt.Printf("DEBUG ResolveConflicts result: hasConflicts=%t, messages=%v\n", hasConflicts, messages)
-
-	// Get all queues for this candidate after conflict resolution
-	var candidateQueues []models.QueueEntry
-	s.db.Where("candidate_id = ? AND status = ?", candidateID, "waiting").
-		Preload("Position").Find(&candidateQueues)
-
-	// If candidate has only one queue, use simple calculation
-	if len(candidateQueues) <= 1 {
-		// Check if there's a resolved EstimatedTime for single queue
-		if len(candidateQueues) == 1 && candidateQueues[0].EstimatedTime != nil {
-			waitDuration := candidateQueues[0].EstimatedTime.Sub(time.Now())
-			if waitDuration > 0 {
-				return int(waitDuration.Minutes())
-			}
-			return 0
-		}
-
-		queuePos := s.getQueuePosition(positionID, candidateID)
-		if queuePos <= 1 {
-			return 0
-		}
-		return (queuePos - 1) * avgInterviewTime
-	}
-
-	// Multiple queues - use the ACTUAL queue positions from database
-	// after conflict resolution has run and updated join times
-	var activity models.ActivityControl
-	s.db.First(&activity)
-
-	// Build a list of all queue positions with their actual wait times
-	type queueInfo struct {
-		PositionID     uint
-		QueuePosition  int
-		ActualWaitTime int
-		JoinTime       time.Time
-	}
-
-	queues := make([]queueInfo, len(candidateQueues))
-
-	// Get the actual queue position for each (after conflict resolution)
-	for i, cq := range candidateQueues {
-		queuePos := s.getQueuePosition(cq.PositionID, candidateID)
-		actualWait := 0
-
-		// Check if this queue entry has a resolved EstimatedTime from conflict resolution
-		if cq.EstimatedTime != nil {
-			// Use the resolved time to calculate wait time
-			waitDuration := cq.EstimatedTime.Sub(time.Now())
-			if waitDuration > 0 {
-				actualWait = int(waitDuration.Minutes())
-			} else {
-				actualWait = 0
-			}
-			fmt.Printf("  Using resolved EstimatedTime for position %d: wait=%d minutes\n", cq.PositionID, actualWait)
-		} else {
-			// Use normal queue position calculation
-			if queuePos > 1 {
-				actualWait = (queuePos - 1) * avgInterviewTime
-			}
-			fmt.Printf("  Using normal calculation for position %d: queuePos=%d, wait=%d minutes\n", cq.PositionID, queuePos, actualWait)
+// reschedule requests a recompute of the event's projected timeline after one
+// of the candidate's queues changed. Once it has run, the candidate's active
+// entries are re-evaluated and their jump aheads are checked.
+func (s *QueueService) reschedule(eventID uint, candidateID uint) {
+	s.schedule.Request(eventID, func() {
+		if err := s.admission.Recompute(eventID, candidateID); err != nil {
+			log.Printf("Failed to recompute active queues for candidate %d: %v", candidateID, err)
 		}
-
-		queues[i] = queueInfo{
-			PositionID:     cq.PositionID,
-			QueuePosition:  queuePos,
-			ActualWaitTime: actualWait,
-			JoinTime:       cq.JoinTime,
-		}
-	}
-
-	// Find and return the actual wait time for the requested position
-	for _, q := range queues {
-		if q.PositionID == positionID {
-			return q.ActualWaitTime
-		}
-	}
-
-	return 0
+		s.suggestJumpAhead(candidateID)
+	})
 }
 
 func (s *QueueService) broadcastQueueUpdate(positionID uint) {
@@ -409,53 +413,6 @@ func (s *QueueService) broadcastQueueUpdate(positionID uint) {
 	s.wsHub.BroadcastToAll(message)
 }
 
-func (s *QueueService) ProcessJumpAhead(candidateID uint, positionID uint) (bool, string) {
-	var entry models.QueueEntry
-	if err := s.db.Where("candidate_id = ? AND position_id = ? AND status = ?",
-		candidateID, positionID, "waiting").First(&entry).Error; err != nil {
-		return false, "Not in queue"
-	}
-
-	if entry.JumpAheadUsed {
-		return false, "Jump ahead already used"
-	}
-
-	var activity models.ActivityControl
-	s.db.First(&activity)
-
-	threshold := activity.AverageInterviewTime + activity.BufferTime
-
-	currentPos := s.getQueuePosition(positionID, candidateID)
-	if currentPos <= 1 {
-		return false, "Already at front of queue"
-	}
-
-	var betterPos int
-	var entries []models.QueueEntry
-	s.db.Where("position_id = ? AND status = ? AND queue_position < ?",
-		positionID, "waiting", currentPos).
-		Order("queue_position DESC").Find(&entries)
-
-	for _, e := range entries {
-		timeSaved := (currentPos - e.QueuePosition) * activity.AverageInterviewTime
-		if timeSaved >= threshold {
-			betterPos = e.QueuePosition + 1
-			break
-		}
-	}
-
-	if betterPos > 0 && betterPos < currentPos {
-		entry.QueuePosition = betterPos
-		entry.JumpAheadUsed = true
-		s.db.Save(&entry)
-		s.updateQueuePositions(positionID)
-		s.broadcastQueueUpdate(positionID)
-		return true, "Jump ahead successful"
-	}
-
-	return false, "No better position available"
-}
-
 // CheckQueueOptimization checks if candidate can optimize their queue order
 func (s *QueueService) CheckQueueOptimization(candidateID uint) (bool, map[string]interface{}) {
 	// Get all queues for this candidate
@@ -467,8 +424,10 @@ func (s *QueueService) CheckQueueOptimization(candidateID uint) (bool, map[strin
 		return false, nil
 	}
 
-	var activity models.ActivityControl
-	s.db.First(&activity)
+	activity, err := s.events.Resolve(candidateQueues[0].EventID)
+	if err != nil {
+		return false, nil
+	}
 
 	// Calculate actual wait times for all positions
 	type queueDetail struct {
@@ -480,9 +439,8 @@ func (s *QueueService) CheckQueueOptimization(candidateID uint) (bool, map[strin
 
 	queues := []queueDetail{}
 	for _, cq := range candidateQueues {
-		pos := s.getQueuePosition(cq.PositionID, candidateID)
-		// Use smart wait time calculation to get optimized times
-		waitTime := s.calculateSmartWaitTime(cq.PositionID, candidateID, activity.AverageInterviewTime)
+		pos := s.getQueuePosition(cq.EventID, cq.PositionID, cq.Round, candidateID)
+		waitTime := s.projectedWait(&cq, activity.AverageInterviewTime)
 
 		queues = append(queues, queueDetail{
 			Entry:      cq,
@@ -557,30 +515,66 @@ func (s *QueueService) ApplyQueueOptimization(candidateID uint, regularPositionI
 	}
 
 	// Calculate current wait times using smart wait time calculation
-	var activity models.ActivityControl
-	s.db.First(&activity)
+	activity, err := s.events.Resolve(priorityEntry.EventID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.policy.Allow(activity, ActionOptimize); err != nil {
+		return err
+	}
 
-	regularWait := s.calculateSmartWaitTime(regularPositionID, candidateID, activity.AverageInterviewTime)
-	priorityWait := s.calculateSmartWaitTime(priorityPositionID, candidateID, activity.AverageInterviewTime)
+	regularWait := s.projectedWait(&regularEntry, activity.AverageInterviewTime)
+	priorityWait := s.projectedWait(&priorityEntry, activity.AverageInterviewTime)
 
 	// Check if optimization is beneficial (regular can be done immediately while priority has wait)
 	if regularWait >= priorityWait {
 		return errors.New("optimization not beneficial")
 	}
 
-	// Swap the join times to reorder the queue
-	// The regular position should come first, so give it an earlier join time
-	tempTime := regularEntry.JoinTime
-	regularEntry.JoinTime = priorityEntry.JoinTime
-	priorityEntry.JoinTime = tempTime.Add(time.Duration(activity.AverageInterviewTime + activity.BufferTime) * time.Minute)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCandidate(tx, candidateID); err != nil {
+			return err
+		}
+
+		// Re-read both entries under the lock in case either was served or left meanwhile
+		if err := tx.Where("id = ? AND status = ?", regularEntry.ID, "waiting").First(&regularEntry).Error; err != nil {
+			return ErrNotInQueue
+		}
+		if err := tx.Where("id = ? AND status = ?", priorityEntry.ID, "waiting").First(&priorityEntry).Error; err != nil {
+			return ErrNotInQueue
+		}
+
+		// Swap the join times to reorder the queue
+		// The regular position should come first, so give it an earlier join time
+		tempTime := regularEntry.JoinTime
+		regularEntry.JoinTime = priorityEntry.JoinTime
+		priorityEntry.JoinTime = tempTime.Add(time.Duration(activity.AverageInterviewTime + activity.BufferTime) * time.Minute)
 
-	// Save the changes
-	s.db.Save(&regularEntry)
-	s.db.Save(&priorityEntry)
+		if err := tx.Model(&regularEntry).UpdateColumn("join_time", regularEntry.JoinTime).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&priorityEntry).UpdateColumn("join_time", priorityEntry.JoinTime).Error; err != nil {
+			return err
+		}
 
-	// Update queue positions for both
-	s.updateQueuePositions(regularPositionID)
-	s.updateQueuePositions(priorityPositionID)
+		// Update queue positions for both, always locking in the same order
+		return reorderQueues(tx,
+			queueRef{regularEntry.EventID, regularPositionID},
+			queueRef{priorityEntry.EventID, priorityPositionID})
+	})
+	if err != nil {
+		return err
+	}
+	s.cache.Add(&regularEntry)
+	s.cache.Add(&priorityEntry)
+	s.reschedule(priorityEntry.EventID, candidateID)
+	s.schedule.Request(priorityEntry.EventID, func() {
+		auditCandidateChanges(s.db, OptimizationReorder, candidateID, []models.QueueEntry{regularEntry, priorityEntry}, map[uint]string{
+			regularEntry.ID:  "Moved ahead of the high priority interview",
+			priorityEntry.ID: "Moved behind the regular interview",
+		})
+	})
 
 	// Broadcast updates
 	s.broadcastQueueUpdate(regularPositionID)
@@ -606,176 +600,95 @@ func (s *QueueService) ProcessDelay(candidateID uint, minutes int) error {
 	var entries []models.QueueEntry
 	s.db.Where("candidate_id = ? AND status = ?", candidateID, "waiting").Find(&entries)
 
-	fmt.Printf("DEBUG ProcessDelay: candidateID=%d, delaying %d minutes for %d positions\n", candidateID, minutes, len(entries))
-
+	checked := make(map[uint]bool)
 	for _, entry := range entries {
-		oldJoinTime := entry.JoinTime
-		newJoinTime := entry.JoinTime.Add(time.Duration(minutes) * time.Minute)
-		entry.JoinTime = newJoinTime
-		entry.DelayUsed++
-		s.db.Save(&entry)
-		s.updateQueuePositions(entry.PositionID)
-
-		fmt.Printf("  Position %d delayed: %s -> %s\n", entry.PositionID, oldJoinTime.Format("15:04:05"), newJoinTime.Format("15:04:05"))
-	}
-
-	return nil
-}
-
-func (s *QueueService) ResolveConflicts(candidateID uint) (bool, []string) {
-	var entries []models.QueueEntry
-	s.db.Where("candidate_id = ? AND status = ?", candidateID, "waiting").
-		Preload("Position").Find(&entries)
-
-	fmt.Printf("DEBUG ResolveConflicts: candidateID=%d, found %d entries\n", candidateID, len(entries))
-	for i, entry := range entries {
-		fmt.Printf("  Entry %d: PositionID=%d, Position=%s, JoinTime=%s\n", i, entry.PositionID, entry.Position.Name, entry.JoinTime.Format("15:04:05"))
-	}
-
-	if len(entries) <= 1 {
-		fmt.Printf("DEBUG ResolveConflicts: Only %d entries, no conflicts possible\n", len(entries))
-		return false, nil
-	}
-
-	type conflictInfo struct {
-		Entry         *models.QueueEntry
-		EstimatedTime time.Time
-		EndTime       time.Time
-		QueuePosition int
-	}
-
-	conflicts := make([]conflictInfo, len(entries))
-	var activity models.ActivityControl
-	s.db.First(&activity)
-
-	// Calculate estimated times for each position
-	for i, entry := range entries {
-		queuePos := s.getQueuePosition(entry.PositionID, candidateID)
-		waitTime := (queuePos - 1) * activity.AverageInterviewTime // Position 1 = 0 wait
-		if waitTime < 0 {
-			waitTime = 0
+		if checked[entry.EventID] {
+			continue
 		}
-
-		// Start time should be the later of:
-		// 1. Queue-based start time (now + queue wait)
-		// 2. Delayed join time (respects user delay)
-		now := time.Now()
-		queueBasedStartTime := now.Add(time.Duration(waitTime) * time.Minute)
-
-		// Handle cross-day join times - if join time appears to be in the past,
-		// it's likely been delayed to the next day
-		delayedStartTime := entry.JoinTime
-		if delayedStartTime.Before(now) {
-			// Add 24 hours to bring it to tomorrow
-			delayedStartTime = delayedStartTime.Add(24 * time.Hour)
+		event, err := s.events.Resolve(entry.EventID)
+		if err != nil {
+			return err
+		}
+		if err := s.policy.Allow(event, ActionDelay); err != nil {
+			return err
 		}
+		checked[entry.EventID] = true
+	}
 
-		fmt.Printf("  Time comparison for %s: Now=%s, QueueBased=%s, DelayedJoin=%s (adjusted=%s)\n",
-			entry.Position.Name,
-			now.Format("15:04:05"),
-			queueBasedStartTime.Format("15:04:05"),
-			entry.JoinTime.Format("15:04:05"),
-			delayedStartTime.Format("15:04:05"))
+	fmt.Printf("DEBUG ProcessDelay: candidateID=%d, delaying %d minutes for %d positions\n", candidateID, minutes, len(entries))
 
-		var startTime time.Time
-		if queueBasedStartTime.After(delayedStartTime) {
-			startTime = queueBasedStartTime
-			fmt.Printf("  Using queue-based start time for %s\n", entry.Position.Name)
-		} else {
-			startTime = delayedStartTime
-			fmt.Printf("  Using delayed join time for %s\n", entry.Position.Name)
+	var locked []models.QueueEntry
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCandidate(tx, candidateID); err != nil {
+			return err
 		}
 
-		endTime := startTime.Add(time.Duration(activity.AverageInterviewTime) * time.Minute)
+		// Re-read under the lock so a concurrent delay can't be applied twice
+		if err := tx.Where("candidate_id = ? AND status = ?", candidateID, "waiting").Find(&locked).Error; err != nil {
+			return err
+		}
 
-		fmt.Printf("  Conflict analysis: Position=%s, QueuePos=%d, WaitTime=%d, StartTime=%s\n",
-			entry.Position.Name, queuePos, waitTime, startTime.Format("15:04:05"))
+		queues := make([]queueRef, 0, len(locked))
+		for i := range locked {
+			entry := &locked[i]
+			oldJoinTime := entry.JoinTime
+			entry.JoinTime = entry.JoinTime.Add(time.Duration(minutes) * time.Minute)
+			entry.DelayUsed++
+			if err := tx.Model(entry).Updates(map[string]interface{}{
+				"join_time":  entry.JoinTime,
+				"delay_used": entry.DelayUsed,
+			}).Error; err != nil {
+				return err
+			}
+			queues = append(queues, queueRef{entry.EventID, entry.PositionID})
 
-		conflicts[i] = conflictInfo{
-			Entry:         &entries[i],
-			EstimatedTime: startTime,
-			EndTime:       endTime,
-			QueuePosition: queuePos,
+			fmt.Printf("  Position %d delayed: %s -> %s\n", entry.PositionID, oldJoinTime.Format("15:04:05"), entry.JoinTime.Format("15:04:05"))
 		}
-	}
 
-	// Sort by priority and estimated time
-	sort.Slice(conflicts, func(i, j int) bool {
-		if conflicts[i].Entry.IsHighPriority != conflicts[j].Entry.IsHighPriority {
-			return conflicts[i].Entry.IsHighPriority
-		}
-		return conflicts[i].EstimatedTime.Before(conflicts[j].EstimatedTime)
+		return reorderQueues(tx, queues...)
 	})
+	if err != nil {
+		return err
+	}
 
-	// Detect and resolve conflicts using a sequential approach
-	hasConflicts := false
-	conflictMessages := []string{}
-
-	// Keep track of the last scheduled end time
-	var lastEndTime time.Time
-
-	for i := 0; i < len(conflicts); i++ {
-		if i == 0 {
-			// First position keeps its original time
-			lastEndTime = conflicts[i].EndTime
-		} else {
-			// Check if current position conflicts with the last scheduled position
-			// A conflict occurs when the current interview would start before the previous one ends
-			// (including buffer time). This covers both overlapping times and simultaneous start times.
-			earliestStartTime := lastEndTime.Add(time.Duration(activity.BufferTime) * time.Minute)
-			fmt.Printf("  Checking conflict for %s: EstimatedTime=%s, EarliestStart=%s\n",
-				conflicts[i].Entry.Position.Name,
-				conflicts[i].EstimatedTime.Format("15:04:05"),
-				earliestStartTime.Format("15:04:05"))
-
-			if conflicts[i].EstimatedTime.Before(earliestStartTime) {
-				fmt.Printf("  CONFLICT DETECTED for %s!\n", conflicts[i].Entry.Position.Name)
-				hasConflicts = true
-
-				// Calculate the new start time with buffer
-				newStartTime := lastEndTime.Add(time.Duration(activity.BufferTime) * time.Minute)
-
-				// Set the resolved EstimatedTime directly
-				conflicts[i].Entry.EstimatedTime = &newStartTime
-
-				// Update estimated times for this entry
-				oldStartTime := conflicts[i].EstimatedTime
-				conflicts[i].EstimatedTime = newStartTime
-				conflicts[i].EndTime = newStartTime.Add(time.Duration(activity.AverageInterviewTime) * time.Minute)
-
-				// Create conflict message
-				msg := fmt.Sprintf("%s was scheduled at %s but conflicts with previous interview. Rescheduled to %s",
-					conflicts[i].Entry.Position.Name,
-					oldStartTime.Format("15:04"),
-					newStartTime.Format("15:04"))
-				conflictMessages = append(conflictMessages, msg)
-
-				// Save the adjusted entry
-				s.db.Save(conflicts[i].Entry)
-				s.updateQueuePositions(conflicts[i].Entry.PositionID)
-
-				// Update lastEndTime for next iteration
-				lastEndTime = conflicts[i].EndTime
-			} else {
-				// No conflict, just update lastEndTime
-				lastEndTime = conflicts[i].EndTime
-			}
+	before := make(map[uint][]models.QueueEntry)
+	var eventIDs []uint
+	details := make(map[uint]string, len(locked))
+	for i := range locked {
+		s.cache.Add(&locked[i])
+		if _, ok := before[locked[i].EventID]; !ok {
+			eventIDs = append(eventIDs, locked[i].EventID)
 		}
+		before[locked[i].EventID] = append(before[locked[i].EventID], locked[i])
+		details[locked[i].ID] = fmt.Sprintf("Delayed by %d minutes", minutes)
+	}
+	for _, eventID := range eventIDs {
+		entries := before[eventID]
+		s.reschedule(eventID, candidateID)
+		s.schedule.Request(eventID, func() {
+			auditCandidateChanges(s.db, OptimizationDelay, candidateID, entries, details)
+		})
 	}
+	return nil
+}
 
-	// Send notification if conflicts were found
-	if hasConflicts && s.wsHub != nil {
-		message := Message{
-			Type: ConflictResolved,
-			Data: map[string]interface{}{
-				"conflicts":    conflictMessages,
-				"resolved":     true,
-				"candidate_id": candidateID,
-			},
-			Timestamp: time.Now(),
+// Conflicts lists the candidate's interviews the schedule had to push back
+// because they would have overlapped another of their interviews. It only
+// reads the stored projection.
+func (s *QueueService) Conflicts(candidateID uint) (bool, []string) {
+	var entries []models.QueueEntry
+	s.db.Preload("Position").
+		Where("candidate_id = ? AND status = ? AND conflict_delayed = ?", candidateID, "waiting", true).
+		Order("estimated_time ASC").Find(&entries)
+
+	messages := []string{}
+	for _, entry := range entries {
+		if entry.EstimatedTime == nil {
+			continue
 		}
-		s.wsHub.BroadcastToUser(candidateID, message)
+		messages = append(messages, fmt.Sprintf("%s is scheduled at %s so it doesn't overlap your other interview",
+			entry.Position.Name, entry.EstimatedTime.Format("15:04")))
 	}
 
-	return hasConflicts, conflictMessages
-}
\ No newline at end of file
+	return len(messages) > 0, messages
+}
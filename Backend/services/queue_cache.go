@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"interview-system/models"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Sorted set scores put each priority level in its own band of
+// queueTierSpacing. Regular and high priority entries are ordered within their
+// band by a millisecond timestamp. Jump ahead entries are ordered by time saved
+// (capped at maxScoredTimeSaved minutes) and then by a timestamp in seconds.
+// Every score stays an exact integer in a float64.
+const (
+	queueTierSpacing   = 1e14
+	timeSavedSpacing   = 1e10
+	maxScoredTimeSaved = 9000
+)
+
+// QueueCache mirrors the first round waiting queue of every position in every
+// event into a Redis sorted set so position and length lookups don't have to load and sort the
+// queue. Later rounds are short and always read from the database.
+// MySQL stays the source of truth: the cache is written through after each
+// committed change, and reads fall back to the database whenever Redis is
+// unavailable or doesn't know the answer.
+type QueueCache struct {
+	client *redis.Client
+	db     *gorm.DB
+}
+
+// NewQueueCache returns a cache backed by the given client. A nil client
+// disables caching and every lookup goes to the database.
+func NewQueueCache(client *redis.Client, db *gorm.DB) *QueueCache {
+	return &QueueCache{client: client, db: db}
+}
+
+func queueCacheKey(eventID, positionID uint) string {
+	return fmt.Sprintf("queue:event:%d:position:%d", eventID, positionID)
+}
+
+func queueCacheMember(candidateID uint) string {
+	return fmt.Sprintf("%d", candidateID)
+}
+
+// queueScore orders entries the same way as models.QueueOrder, lowest first.
+func queueScore(entry *models.QueueEntry) float64 {
+	band := float64(models.PriorityHigh-entry.Priority) * queueTierSpacing
+
+	stamp := entry.JoinTime
+	if entry.PrioritySetTime != nil && entry.Priority > models.PriorityRegular {
+		stamp = *entry.PrioritySetTime
+	}
+
+	if entry.Priority == models.PriorityJumpAhead {
+		saved := entry.TimeSaved
+		if saved > maxScoredTimeSaved {
+			saved = maxScoredTimeSaved
+		}
+		if saved < 0 {
+			saved = 0
+		}
+		return band + float64(maxScoredTimeSaved-saved)*timeSavedSpacing + float64(stamp.Unix())
+	}
+	return band + float64(stamp.UnixMilli())
+}
+
+func (c *QueueCache) enabled() bool {
+	return c != nil && c.client != nil
+}
+
+// Add writes a waiting entry to its queue's set, or moves it if its score
+// changed.
+func (c *QueueCache) Add(entry *models.QueueEntry) {
+	if !c.enabled() {
+		return
+	}
+	if entryRound(entry.Round) > 1 {
+		c.Remove(entry.EventID, entry.PositionID, entry.CandidateID)
+		return
+	}
+	ctx := context.Background()
+	key := queueCacheKey(entry.EventID, entry.PositionID)
+
+	// A missing set means the queue was never loaded or Redis lost it; adding a
+	// single member would make it look like the whole queue
+	if n, err := c.client.Exists(ctx, key).Result(); err == nil && n == 0 {
+		c.Refresh(entry.EventID, entry.PositionID)
+		return
+	}
+
+	err := c.client.ZAdd(ctx, key, redis.Z{
+		Score:  queueScore(entry),
+		Member: queueCacheMember(entry.CandidateID),
+	}).Err()
+	if err != nil {
+		c.fail(entry.EventID, entry.PositionID, err)
+	}
+}
+
+// Remove drops the candidate from the queue's set once they stop waiting.
+func (c *QueueCache) Remove(eventID, positionID, candidateID uint) {
+	if !c.enabled() {
+		return
+	}
+	ctx := context.Background()
+	if err := c.client.ZRem(ctx, queueCacheKey(eventID, positionID), queueCacheMember(candidateID)).Err(); err != nil {
+		c.fail(eventID, positionID, err)
+	}
+}
+
+// Refresh rebuilds the queue's set from the database. It is used after bulk
+// changes where reloading is simpler than tracking individual entries.
+func (c *QueueCache) Refresh(eventID, positionID uint) {
+	if !c.enabled() {
+		return
+	}
+
+	var entries []models.QueueEntry
+	if err := c.db.Select("candidate_id", "position_id", "priority", "time_saved", "priority_set_time", "join_time").
+		Where("event_id = ? AND position_id = ? AND round = ? AND status = ?", eventID, positionID, 1, "waiting").
+		Find(&entries).Error; err != nil {
+		c.fail(eventID, positionID, err)
+		return
+	}
+
+	members := make([]redis.Z, len(entries))
+	for i := range entries {
+		members[i] = redis.Z{Score: queueScore(&entries[i]), Member: queueCacheMember(entries[i].CandidateID)}
+	}
+
+	ctx := context.Background()
+	key := queueCacheKey(eventID, positionID)
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(members) > 0 {
+			pipe.ZAdd(ctx, key, members...)
+		}
+		return nil
+	})
+	if err != nil {
+		c.fail(eventID, positionID, err)
+	}
+}
+
+// Warm loads every queue that currently has people waiting, e.g. on startup
+// when Redis may have been emptied.
+func (c *QueueCache) Warm() {
+	if !c.enabled() {
+		return
+	}
+
+	var queues []models.QueueEntry
+	if err := c.db.Model(&models.QueueEntry{}).Where("status = ?", "waiting").
+		Distinct("event_id", "position_id").Find(&queues).Error; err != nil {
+		log.Printf("Queue cache: failed to load queues: %v", err)
+		return
+	}
+	for _, queue := range queues {
+		c.Refresh(queue.EventID, queue.PositionID)
+	}
+}
+
+// Rank returns the candidate's 1-based place in the event's queue for the
+// position. ok is false when the cache can't answer and the caller should ask
+// the database.
+func (c *QueueCache) Rank(eventID, positionID, candidateID uint) (rank int, ok bool) {
+	if !c.enabled() {
+		return 0, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	r, err := c.client.ZRank(ctx, queueCacheKey(eventID, positionID), queueCacheMember(candidateID)).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("Queue cache: rank lookup for position %d failed: %v", positionID, err)
+		}
+		return 0, false
+	}
+	return int(r) + 1, true
+}
+
+// Length returns how many candidates wait for the position in the event. ok
+// is false when the cache can't answer; an empty set is reported that way
+// too, since it can't be told apart from a queue that was never cached.
+func (c *QueueCache) Length(eventID, positionID uint) (length int, ok bool) {
+	if !c.enabled() {
+		return 0, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	n, err := c.client.ZCard(ctx, queueCacheKey(eventID, positionID)).Result()
+	if err != nil {
+		log.Printf("Queue cache: length lookup for position %d failed: %v", positionID, err)
+		return 0, false
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// fail drops a queue's set after a failed write so readers fall back to the
+// database instead of trusting a set that missed an update.
+func (c *QueueCache) fail(eventID, positionID uint, err error) {
+	log.Printf("Queue cache: update for event %d position %d failed: %v", eventID, positionID, err)
+	c.client.Del(context.Background(), queueCacheKey(eventID, positionID))
+}
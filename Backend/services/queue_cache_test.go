@@ -0,0 +1,189 @@
+package services
+
+import (
+	"interview-system/models"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestQueueCache returns a queue service whose cache is backed by an
+// in-memory Redis, along with that Redis.
+func newTestQueueCache(t *testing.T) (*QueueService, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	s := newTestQueueService(t)
+	s.cache = NewQueueCache(client, s.db)
+	return s, mr
+}
+
+// addWaiting stores a waiting first round entry and writes it through to the
+// cache.
+func addWaiting(t *testing.T, s *QueueService, entry models.QueueEntry) models.QueueEntry {
+	t.Helper()
+
+	entry.Status = "waiting"
+	entry.Round = 1
+	entry.OpenKey = models.QueueOpenKey(entry.EventID, entry.CandidateID, entry.PositionID)
+	if err := s.db.Create(&entry).Error; err != nil {
+		t.Fatalf("create entry: %v", err)
+	}
+	s.cache.Add(&entry)
+	return entry
+}
+
+func TestQueueCacheTracksQueue(t *testing.T) {
+	s, mr := newTestQueueCache(t)
+	event, positionID, candidates := seedQueue(t, s, 3)
+
+	now := time.Now()
+	for i, candidateID := range candidates {
+		addWaiting(t, s, models.QueueEntry{
+			EventID:     event.ID,
+			CandidateID: candidateID,
+			PositionID:  positionID,
+			Priority:    models.PriorityRegular,
+			JoinTime:    now.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	// The first Add found no set and loaded the whole queue from the database
+	key := queueCacheKey(event.ID, positionID)
+	if members, err := mr.ZMembers(key); err != nil || len(members) != len(candidates) {
+		t.Fatalf("cached members = %v (%v), want %d", members, err, len(candidates))
+	}
+	if length, ok := s.cache.Length(event.ID, positionID); !ok || length != 3 {
+		t.Errorf("Length = %d, %v, want 3, true", length, ok)
+	}
+	for i, candidateID := range candidates {
+		if rank, ok := s.cache.Rank(event.ID, positionID, candidateID); !ok || rank != i+1 {
+			t.Errorf("Rank of candidate %d = %d, %v, want %d, true", candidateID, rank, ok, i+1)
+		}
+	}
+
+	s.cache.Remove(event.ID, positionID, candidates[0])
+	if length, ok := s.cache.Length(event.ID, positionID); !ok || length != 2 {
+		t.Errorf("Length after Remove = %d, %v, want 2, true", length, ok)
+	}
+	if rank, ok := s.cache.Rank(event.ID, positionID, candidates[1]); !ok || rank != 1 {
+		t.Errorf("Rank after Remove = %d, %v, want 1, true", rank, ok)
+	}
+	if _, ok := s.cache.Rank(event.ID, positionID, candidates[0]); ok {
+		t.Error("removed candidate still ranked")
+	}
+
+	// Later rounds are never cached
+	s.cache.Add(&models.QueueEntry{EventID: event.ID, CandidateID: candidates[1], PositionID: positionID, Round: 2})
+	if _, ok := s.cache.Rank(event.ID, positionID, candidates[1]); ok {
+		t.Error("candidate moving on to round 2 still ranked in round 1")
+	}
+}
+
+func TestQueueCacheScoreOrder(t *testing.T) {
+	s, _ := newTestQueueCache(t)
+	event, positionID, candidates := seedQueue(t, s, 5)
+
+	now := time.Now()
+	early, late := now.Add(-30*time.Minute), now.Add(-10*time.Minute)
+	entries := []models.QueueEntry{
+		{CandidateID: candidates[0], Priority: models.PriorityRegular, JoinTime: early},
+		{CandidateID: candidates[1], Priority: models.PriorityJumpAhead, JoinTime: late, TimeSaved: 10, PrioritySetTime: &early},
+		{CandidateID: candidates[2], Priority: models.PriorityHigh, JoinTime: late, PrioritySetTime: &late},
+		{CandidateID: candidates[3], Priority: models.PriorityJumpAhead, JoinTime: late, TimeSaved: 30, PrioritySetTime: &late},
+		{CandidateID: candidates[4], Priority: models.PriorityHigh, JoinTime: late, PrioritySetTime: &early},
+	}
+	for i := range entries {
+		entries[i].EventID = event.ID
+		entries[i].PositionID = positionID
+		entries[i] = addWaiting(t, s, entries[i])
+	}
+
+	// High priority by when it was set, then jump ahead by time saved, then
+	// regular, matching the database order
+	want := []uint{candidates[4], candidates[2], candidates[3], candidates[1], candidates[0]}
+	sort.SliceStable(entries, func(i, j int) bool { return models.QueueEntryLess(&entries[i], &entries[j]) })
+	for i := range entries {
+		if entries[i].CandidateID != want[i] {
+			t.Fatalf("QueueEntryLess puts candidate %d at %d, want %d", entries[i].CandidateID, i+1, want[i])
+		}
+	}
+	for i, candidateID := range want {
+		if rank, ok := s.cache.Rank(event.ID, positionID, candidateID); !ok || rank != i+1 {
+			t.Errorf("Rank of candidate %d = %d, %v, want %d, true", candidateID, rank, ok, i+1)
+		}
+		if place := s.getQueuePosition(event.ID, positionID, 1, candidateID); place != i+1 {
+			t.Errorf("queue position of candidate %d = %d, want %d", candidateID, place, i+1)
+		}
+	}
+}
+
+func TestQueueCacheFallsBackWhenRedisIsDown(t *testing.T) {
+	s, mr := newTestQueueCache(t)
+	event, positionID, candidates := seedQueue(t, s, 3)
+
+	now := time.Now()
+	for i, candidateID := range candidates {
+		addWaiting(t, s, models.QueueEntry{
+			EventID:     event.ID,
+			CandidateID: candidateID,
+			PositionID:  positionID,
+			Priority:    models.PriorityRegular,
+			JoinTime:    now.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	mr.Close()
+
+	if _, ok := s.cache.Length(event.ID, positionID); ok {
+		t.Error("Length answered with Redis down")
+	}
+	if _, ok := s.cache.Rank(event.ID, positionID, candidates[0]); ok {
+		t.Error("Rank answered with Redis down")
+	}
+	if length := s.getQueueLength(event.ID, positionID, 1); length != 3 {
+		t.Errorf("queue length = %d, want 3 from the database", length)
+	}
+	if place := s.getQueuePosition(event.ID, positionID, 1, candidates[2]); place != 3 {
+		t.Errorf("queue position = %d, want 3 from the database", place)
+	}
+
+	// Writes while Redis is down must not panic or block
+	s.cache.Remove(event.ID, positionID, candidates[0])
+	s.cache.Add(&models.QueueEntry{EventID: event.ID, CandidateID: candidates[0], PositionID: positionID, Round: 1})
+}
+
+func TestQueueCacheReloadsLostQueue(t *testing.T) {
+	s, mr := newTestQueueCache(t)
+	event, positionID, candidates := seedQueue(t, s, 3)
+
+	now := time.Now()
+	var entries []models.QueueEntry
+	for i, candidateID := range candidates {
+		entries = append(entries, addWaiting(t, s, models.QueueEntry{
+			EventID:     event.ID,
+			CandidateID: candidateID,
+			PositionID:  positionID,
+			Priority:    models.PriorityRegular,
+			JoinTime:    now.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	// Redis restarted empty: the next write reloads the queue instead of
+	// leaving a set that only holds the changed entry
+	mr.FlushAll()
+	s.cache.Add(&entries[1])
+
+	if length, ok := s.cache.Length(event.ID, positionID); !ok || length != 3 {
+		t.Errorf("Length = %d, %v, want 3, true", length, ok)
+	}
+	if rank, ok := s.cache.Rank(event.ID, positionID, candidates[2]); !ok || rank != 3 {
+		t.Errorf("Rank = %d, %v, want 3, true", rank, ok)
+	}
+}
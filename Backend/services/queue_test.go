@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"interview-system/config"
+	"interview-system/models"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestQueueService(t *testing.T) *QueueService {
+	t.Helper()
+
+	db := newTestDB(t)
+	hub := NewWebSocketHub()
+	go hub.Run()
+	return NewQueueService(db, hub,
+		NewEventService(db, config.QueueConfig{}),
+		NewActivityPolicy(nil),
+		NewQueueCache(nil, db),
+		NewScheduleEngine(db, hub, nil),
+		NewAdmission(db, hub),
+		NewApplicationService(db, NewNotificationService(db, hub)))
+}
+
+// seedQueue creates a running event, a position and the given number of
+// candidates, and returns the position and candidate IDs.
+func seedQueue(t *testing.T, s *QueueService, candidates int) (*models.Event, uint, []uint) {
+	t.Helper()
+
+	now := time.Now()
+	event := models.Event{
+		Name:                 "Fair",
+		Date:                 now,
+		StartTime:            now.Add(-time.Hour),
+		EndTime:              now.Add(4 * time.Hour),
+		Status:               models.EventActive,
+		ActiveQueueLimit:     3,
+		HighPriorityQuota:    1,
+		AverageInterviewTime: 15,
+		BufferTime:           5,
+	}
+	company := models.Company{Name: "Acme", Code: "ACME"}
+	if err := s.db.Create(&event).Error; err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	if err := s.db.Create(&company).Error; err != nil {
+		t.Fatalf("create company: %v", err)
+	}
+	position := models.Position{Name: "Engineer", CompanyID: company.ID}
+	if err := s.db.Create(&position).Error; err != nil {
+		t.Fatalf("create position: %v", err)
+	}
+
+	ids := make([]uint, candidates)
+	for i := range ids {
+		user := models.User{
+			Account:  fmt.Sprintf("candidate%d", i),
+			Password: "x",
+			Name:     fmt.Sprintf("Candidate %d", i),
+			Role:     models.RoleCandidate,
+		}
+		if err := s.db.Create(&user).Error; err != nil {
+			t.Fatalf("create candidate: %v", err)
+		}
+		ids[i] = user.ID
+	}
+	return &event, position.ID, ids
+}
+
+func TestQueuePositionsStayContiguousUnderConcurrentChanges(t *testing.T) {
+	s := newTestQueueService(t)
+	event, positionID, candidates := seedQueue(t, s, 24)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(candidates)*8)
+	for i, candidateID := range candidates {
+		wg.Add(1)
+		go func(i int, candidateID uint) {
+			defer wg.Done()
+
+			if err := s.JoinQueue(candidateID, positionID); err != nil {
+				errs <- fmt.Errorf("candidate %d join: %w", candidateID, err)
+				return
+			}
+			for round := 0; round < 3; round++ {
+				var err error
+				switch (i + round) % 3 {
+				case 0:
+					if err = s.LeaveQueue(candidateID, positionID); err == nil {
+						err = s.JoinQueue(candidateID, positionID)
+					}
+				case 1:
+					err = s.ProcessDelay(candidateID, 5)
+				case 2:
+					err = s.ProcessDelay(candidateID, 1)
+				}
+				if err != nil {
+					errs <- fmt.Errorf("candidate %d step %d: %w", candidateID, round, err)
+					return
+				}
+			}
+			if i%2 == 0 {
+				if err := s.LeaveQueue(candidateID, positionID); err != nil {
+					errs <- fmt.Errorf("candidate %d leave: %w", candidateID, err)
+				}
+			}
+		}(i, candidateID)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var waiting []models.QueueEntry
+	if err := s.db.Where("event_id = ? AND position_id = ? AND status = ?", event.ID, positionID, "waiting").
+		Order(models.QueueOrder).Find(&waiting).Error; err != nil {
+		t.Fatalf("load queue: %v", err)
+	}
+	if len(waiting) != len(candidates)/2 {
+		t.Fatalf("%d candidates waiting, want %d", len(waiting), len(candidates)/2)
+	}
+	seen := make(map[uint]bool, len(waiting))
+	for i, entry := range waiting {
+		if entry.QueuePosition != i+1 {
+			t.Errorf("entry %d of candidate %d has queue position %d, want %d",
+				entry.ID, entry.CandidateID, entry.QueuePosition, i+1)
+		}
+		if seen[entry.CandidateID] {
+			t.Errorf("candidate %d is waiting twice", entry.CandidateID)
+		}
+		seen[entry.CandidateID] = true
+	}
+}
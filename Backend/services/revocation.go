@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// revocationRetry is how long the list answers from memory alone after Redis
+// failed, before it tries Redis again.
+const revocationRetry = 10 * time.Second
+
+// RevocationList records revoked sessions and users until their access tokens
+// would have expired anyway. Entries are written to Redis, so every instance
+// sees them, and to an in-memory map that answers when Redis can't. After a
+// Redis error the list stops calling Redis for revocationRetry, so requests
+// don't each wait out a timeout, and writes the revocations made meanwhile
+// once Redis answers again.
+type RevocationList struct {
+	client   *redis.Client
+	mu       sync.Mutex
+	local    map[string]revocation
+	unsynced map[string]bool
+	retryAt  time.Time
+}
+
+type revocation struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// NewRevocationList returns a list backed by the given client. A nil client
+// keeps revocations in memory only.
+func NewRevocationList(client *redis.Client) *RevocationList {
+	return &RevocationList{client: client, local: make(map[string]revocation), unsynced: make(map[string]bool)}
+}
+
+func sessionRevocationKey(sessionID string) string {
+	return "revoked:session:" + sessionID
+}
+
+func userRevocationKey(userID uint) string {
+	return fmt.Sprintf("revoked:user:%d", userID)
+}
+
+// RevokeSession rejects every access token of the session from now on.
+func (r *RevocationList) RevokeSession(sessionID string, ttl time.Duration) {
+	r.set(sessionRevocationKey(sessionID), 1, ttl)
+}
+
+// RevokeUser rejects every access token issued to the user up to now. The
+// time is kept in milliseconds, so a token issued right after, in the same
+// second, still works.
+func (r *RevocationList) RevokeUser(userID uint, ttl time.Duration) {
+	r.set(userRevocationKey(userID), time.Now().UnixMilli(), ttl)
+}
+
+// SessionRevoked reports whether the session has been logged out.
+func (r *RevocationList) SessionRevoked(sessionID string) bool {
+	_, ok := r.get(sessionRevocationKey(sessionID))
+	return ok
+}
+
+// UserRevokedAt returns when the user's tokens were last revoked.
+func (r *RevocationList) UserRevokedAt(userID uint) (time.Time, bool) {
+	value, ok := r.get(userRevocationKey(userID))
+	if !ok {
+		return time.Time{}, false
+	}
+	if value < 1e12 {
+		// Written in seconds before revocations kept milliseconds
+		return time.Unix(value, 0), true
+	}
+	return time.UnixMilli(value), true
+}
+
+func (r *RevocationList) set(key string, value int64, ttl time.Duration) {
+	r.mu.Lock()
+	if existing, ok := r.local[key]; !ok || value > existing.value {
+		r.local[key] = revocation{value: value, expiresAt: time.Now().Add(ttl)}
+	}
+	r.pruneLocked()
+	available := r.availableLocked()
+	if r.client != nil && !available {
+		r.unsynced[key] = true
+	}
+	r.mu.Unlock()
+
+	if !available {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := r.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		r.failed(err, key)
+		return
+	}
+	r.resync()
+}
+
+// get checks Redis and memory, so a revocation made while Redis was down, or
+// on another instance, is found either way.
+func (r *RevocationList) get(key string) (int64, bool) {
+	r.mu.Lock()
+	entry, found := r.local[key]
+	if found && time.Now().After(entry.expiresAt) {
+		delete(r.local, key)
+		found = false
+	}
+	available := r.availableLocked()
+	r.mu.Unlock()
+
+	if !available {
+		return entry.value, found
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	value, err := r.client.Get(ctx, key).Result()
+	if err != nil && err != redis.Nil {
+		r.failed(err, "")
+		return entry.value, found
+	}
+	r.resync()
+	if err == nil {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil && (!found || parsed > entry.value) {
+			return parsed, true
+		}
+	}
+	return entry.value, found
+}
+
+// availableLocked reports whether Redis may be called, which it may not for a
+// while after it failed.
+func (r *RevocationList) availableLocked() bool {
+	return r.client != nil && !time.Now().Before(r.retryAt)
+}
+
+// failed stops calls to Redis for revocationRetry. A key that couldn't be
+// written is kept for resync.
+func (r *RevocationList) failed(err error, key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if key != "" {
+		r.unsynced[key] = true
+	}
+	if !time.Now().Before(r.retryAt) {
+		log.Printf("Revocation list: Redis unavailable, using memory for %s: %v", revocationRetry, err)
+	}
+	r.retryAt = time.Now().Add(revocationRetry)
+}
+
+// resync writes the revocations made while Redis was unavailable, unless
+// Redis already holds a later one.
+func (r *RevocationList) resync() {
+	r.mu.Lock()
+	if len(r.unsynced) == 0 {
+		r.mu.Unlock()
+		return
+	}
+	pending := make(map[string]revocation, len(r.unsynced))
+	for key := range r.unsynced {
+		if entry, ok := r.local[key]; ok {
+			pending[key] = entry
+		}
+	}
+	r.unsynced = make(map[string]bool)
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	for key, entry := range pending {
+		ttl := time.Until(entry.expiresAt)
+		if ttl <= 0 {
+			continue
+		}
+		stored, err := r.client.Get(ctx, key).Int64()
+		if err == nil && stored >= entry.value {
+			continue
+		}
+		if err == nil || err == redis.Nil {
+			err = r.client.Set(ctx, key, entry.value, ttl).Err()
+		}
+		if err != nil {
+			for key := range pending {
+				r.failed(err, key)
+			}
+			return
+		}
+	}
+}
+
+func (r *RevocationList) pruneLocked() {
+	now := time.Now()
+	for key, entry := range r.local {
+		if now.After(entry.expiresAt) {
+			delete(r.local, key)
+		}
+	}
+}
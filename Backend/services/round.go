@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"interview-system/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// roundKey identifies one round's queue of a position.
+type roundKey struct {
+	positionID uint
+	round      int
+}
+
+// entryRound is the round of a queue entry or interview; rows written before
+// rounds existed count as round 1.
+func entryRound(round int) int {
+	if round < 1 {
+		return 1
+	}
+	return round
+}
+
+// RoundPool is the set of position rounds an interviewer interviews for. A
+// position mapped to nil is covered in every round.
+type RoundPool map[uint]map[int]bool
+
+// Covers reports whether the interviewer interviews the position's round.
+func (p RoundPool) Covers(positionID uint, round int) bool {
+	rounds, ok := p[positionID]
+	return ok && (rounds == nil || rounds[entryRound(round)])
+}
+
+// InterviewerRoundPool loads the rounds the interviewer is assigned to.
+func InterviewerRoundPool(db *gorm.DB, interviewerID uint) (RoundPool, error) {
+	var assignments []models.PositionInterviewer
+	if err := db.Where("interviewer_id = ?", interviewerID).Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+
+	pool := make(RoundPool)
+	allRounds := make(map[uint]bool)
+	for _, assignment := range assignments {
+		if assignment.Round == 0 {
+			allRounds[assignment.PositionID] = true
+			pool[assignment.PositionID] = nil
+			continue
+		}
+		if allRounds[assignment.PositionID] {
+			continue
+		}
+		if pool[assignment.PositionID] == nil {
+			pool[assignment.PositionID] = make(map[int]bool)
+		}
+		pool[assignment.PositionID][assignment.Round] = true
+	}
+	return pool, nil
+}
+
+// nextRound returns the round that follows the given one, nil after the last.
+func nextRound(tx *gorm.DB, positionID uint, round int) (*models.PositionRound, error) {
+	var next models.PositionRound
+	err := tx.Where("position_id = ? AND number = ?", positionID, entryRound(round)+1).First(&next).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// queueNextRound puts a candidate who passed a round at the back of the next
+// round's queue. It returns nil when the interview was the last round.
+func queueNextRound(tx *gorm.DB, interview *models.Interview) (*models.QueueEntry, *models.PositionRound, error) {
+	next, err := nextRound(tx, interview.PositionID, interview.Round)
+	if err != nil || next == nil {
+		return nil, nil, err
+	}
+
+	entry := models.QueueEntry{
+		EventID:     interview.EventID,
+		CandidateID: interview.CandidateID,
+		PositionID:  interview.PositionID,
+		Round:       next.Number,
+		JoinTime:    time.Now(),
+		Priority:    models.PriorityRegular,
+		IsActive:    true,
+		Status:      "waiting",
+		OpenKey:     models.QueueOpenKey(interview.EventID, interview.CandidateID, interview.PositionID),
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := reorderQueue(tx, interview.EventID, interview.PositionID); err != nil {
+		return nil, nil, err
+	}
+	return &entry, next, nil
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"fmt"
+	"interview-system/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// scheduleTolerance is how far a projected start may drift before it is
+// written back, so routine recomputes don't rewrite every row.
+const scheduleTolerance = 30 * time.Second
+
+// scheduleDebounce is how long the engine gathers requests before
+// recomputing, so a burst of queue changes costs one recompute per event.
+const scheduleDebounce = 250 * time.Millisecond
+
+// ScheduleEngine projects when every waiting candidate of an event will be
+// interviewed. It walks all of the event's queues together, so neither a
+// candidate waiting for several positions nor an interviewer covering several
+// positions is ever booked into overlapping interviews,
+// and stores the result on each entry as EstimatedTime. Reads use the stored
+// projection. Queue changes request a recompute, which runs in the
+// background once per burst of changes, and running events are refreshed
+// periodically as interviews run over or finish early.
+type ScheduleEngine struct {
+	db       *gorm.DB
+	wsHub    *WebSocketHub
+	clock    Clock
+	interval time.Duration
+	mu       sync.Mutex
+
+	pendingMu sync.Mutex
+	pending   map[uint][]func()
+	wake      chan struct{}
+}
+
+func NewScheduleEngine(db *gorm.DB, wsHub *WebSocketHub, clock Clock) *ScheduleEngine {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &ScheduleEngine{
+		db:       db,
+		wsHub:    wsHub,
+		clock:    clock,
+		interval: time.Minute,
+		pending:  make(map[uint][]func()),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Run recomputes requested events as requests come in and refreshes every
+// running event on each tick.
+func (e *ScheduleEngine) Run() {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.RecomputeRunning()
+		case <-e.wake:
+			time.Sleep(scheduleDebounce)
+			e.flush()
+		}
+	}
+}
+
+// Request queues a recompute of the event and returns at once. Requests for
+// the same event that arrive before the engine gets to it share one
+// recompute. then, if not nil, runs after that recompute, once the projection
+// is fresh.
+func (e *ScheduleEngine) Request(eventID uint, then func()) {
+	e.pendingMu.Lock()
+	e.pending[eventID] = append(e.pending[eventID], then)
+	e.pendingMu.Unlock()
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush recomputes every requested event and runs what was waiting on it.
+func (e *ScheduleEngine) flush() {
+	e.pendingMu.Lock()
+	pending := e.pending
+	e.pending = make(map[uint][]func())
+	e.pendingMu.Unlock()
+
+	for eventID, thens := range pending {
+		if err := e.Recompute(eventID); err != nil {
+			log.Printf("Schedule engine: failed to recompute event %d: %v", eventID, err)
+		}
+		for _, then := range thens {
+			if then != nil {
+				then()
+			}
+		}
+	}
+}
+
+// RecomputeRunning refreshes the projection of every running event.
+func (e *ScheduleEngine) RecomputeRunning() {
+	var eventIDs []uint
+	if err := e.db.Model(&models.Event{}).
+		Where("archived_at IS NULL AND status IN ?", []string{models.EventActive, models.EventClosing, models.EventFinalCall}).
+		Pluck("id", &eventIDs).Error; err != nil {
+		log.Printf("Schedule engine: failed to load events: %v", err)
+		return
+	}
+	for _, eventID := range eventIDs {
+		if err := e.Recompute(eventID); err != nil {
+			log.Printf("Schedule engine: failed to recompute event %d: %v", eventID, err)
+		}
+	}
+}
+
+// Recompute rebuilds the timeline of one event.
+//
+// Each round of a position serves its queue strictly in order. A round is
+// served by the interviewers assigned to it or to every round of the
+// position, each doing one interview at a time across all of their
+// positions; a round without interviewers serves one candidate at a time on
+// its own. Rounds are sequential: a candidate only queues for a round once they
+// have passed the previous one, and their buffer after that interview applies
+// as for any other. The engine repeatedly takes, among the heads of all
+// queues, the entry that can start soonest: not before one of the position's
+// interviewers (or the position) is free, not before the
+// candidate's previous interview plus the buffer has ended, and not before a
+// delayed join time. Entries held back by the candidate's own other interview
+// are flagged as conflict delayed; the candidate is told about it once and the
+// reschedule is recorded in the queue audit trail.
+func (e *ScheduleEngine) Recompute(eventID uint) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var event models.Event
+	if err := e.db.First(&event, eventID).Error; err != nil {
+		return err
+	}
+
+	var entries []models.QueueEntry
+	if err := e.db.Preload("Position").
+		Where("event_id = ? AND status = ?", event.ID, "waiting").
+		Order(models.QueueOrder).Find(&entries).Error; err != nil {
+		return err
+	}
+
+	var interviews []models.Interview
+	if err := e.db.Where("event_id = ? AND status = ?", event.ID, models.InterviewInProgress).
+		Find(&interviews).Error; err != nil {
+		return err
+	}
+
+	var called []models.QueueEntry
+	if err := e.db.Where("event_id = ? AND status = ?", event.ID, "called").
+		Find(&called).Error; err != nil {
+		return err
+	}
+
+	var assignments []models.PositionInterviewer
+	if err := e.db.Order("interviewer_id ASC").Find(&assignments).Error; err != nil {
+		return err
+	}
+	positionAssignments := make(map[uint][]models.PositionInterviewer)
+	for _, assignment := range assignments {
+		positionAssignments[assignment.PositionID] = append(positionAssignments[assignment.PositionID], assignment)
+	}
+
+	now := e.clock.Now()
+	interviewLength := time.Duration(event.AverageInterviewTime) * time.Minute
+	buffer := time.Duration(event.BufferTime) * time.Minute
+
+	roundFree := make(map[roundKey]time.Time)
+	interviewerFree := make(map[uint]time.Time)
+	candidateFree := make(map[uint]time.Time)
+	occupy := func(key roundKey, interviewerID, candidateID uint, start *time.Time) {
+		end := now
+		if start != nil && start.Add(interviewLength).After(now) {
+			end = start.Add(interviewLength)
+		}
+		if end.After(roundFree[key]) {
+			roundFree[key] = end
+		}
+		if interviewerID != 0 && end.After(interviewerFree[interviewerID]) {
+			interviewerFree[interviewerID] = end
+		}
+		if end.Add(buffer).After(candidateFree[candidateID]) {
+			candidateFree[candidateID] = end.Add(buffer)
+		}
+	}
+	for _, interview := range interviews {
+		occupy(roundKey{interview.PositionID, entryRound(interview.Round)}, interview.InterviewerID, interview.CandidateID, interview.StartTime)
+	}
+	// A called candidate is about to be interviewed, count it from the call
+	for _, entry := range called {
+		var interviewerID uint
+		if entry.CalledBy != nil {
+			interviewerID = *entry.CalledBy
+		}
+		occupy(roundKey{entry.PositionID, entryRound(entry.Round)}, interviewerID, entry.CandidateID, entry.CalledAt)
+	}
+
+	// serverFree is when the round can next take a candidate and which
+	// interviewer takes them, 0 for a round nobody is assigned to.
+	serverFree := func(key roundKey) (time.Time, uint) {
+		var first uint
+		for _, assignment := range positionAssignments[key.positionID] {
+			if assignment.Round != 0 && assignment.Round != key.round {
+				continue
+			}
+			if first == 0 || interviewerFree[assignment.InterviewerID].Before(interviewerFree[first]) {
+				first = assignment.InterviewerID
+			}
+		}
+		if first == 0 {
+			return roundFree[key], 0
+		}
+		return interviewerFree[first], first
+	}
+
+	queues := make(map[roundKey][]*models.QueueEntry)
+	for i := range entries {
+		key := roundKey{entries[i].PositionID, entryRound(entries[i].Round)}
+		queues[key] = append(queues[key], &entries[i])
+	}
+	keys := make([]roundKey, 0, len(queues))
+	for key := range queues {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].positionID != keys[j].positionID {
+			return keys[i].positionID < keys[j].positionID
+		}
+		return keys[i].round < keys[j].round
+	})
+
+	earliestStart := func(key roundKey, entry *models.QueueEntry) (time.Time, bool, uint) {
+		start := now
+		free, interviewerID := serverFree(key)
+		if free.After(start) {
+			start = free
+		}
+		delayed := false
+		if free := candidateFree[entry.CandidateID]; free.After(start) {
+			start = free
+			delayed = true
+		}
+		if entry.JoinTime.After(start) {
+			start = entry.JoinTime
+			delayed = false
+		}
+		return start, delayed, interviewerID
+	}
+
+	type projection struct {
+		start   time.Time
+		delayed bool
+	}
+	projected := make(map[uint]projection, len(entries))
+	heads := make(map[roundKey]int, len(queues))
+
+	for remaining := len(entries); remaining > 0; remaining-- {
+		var next *models.QueueEntry
+		var nextKey roundKey
+		var nextStart time.Time
+		var nextDelayed bool
+		var nextInterviewer uint
+		for _, key := range keys {
+			queue := queues[key]
+			if heads[key] >= len(queue) {
+				continue
+			}
+			entry := queue[heads[key]]
+			start, delayed, interviewerID := earliestStart(key, entry)
+			if next == nil || start.Before(nextStart) ||
+				(start.Equal(nextStart) && entry.Priority > next.Priority) {
+				next, nextKey, nextStart, nextDelayed, nextInterviewer = entry, key, start, delayed, interviewerID
+			}
+		}
+
+		heads[nextKey]++
+		projected[next.ID] = projection{start: nextStart, delayed: nextDelayed}
+		roundFree[nextKey] = nextStart.Add(interviewLength)
+		if nextInterviewer != 0 {
+			interviewerFree[nextInterviewer] = nextStart.Add(interviewLength)
+		}
+		candidateFree[next.CandidateID] = nextStart.Add(interviewLength + buffer)
+	}
+
+	notices := make(map[uint][]string)
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		for i := range entries {
+			entry := &entries[i]
+			p := projected[entry.ID]
+
+			unchanged := entry.EstimatedTime != nil && entry.ConflictDelayed == p.delayed &&
+				entry.EstimatedTime.Sub(p.start).Abs() < scheduleTolerance
+			if unchanged {
+				continue
+			}
+
+			if p.delayed && !entry.ConflictDelayed {
+				message := fmt.Sprintf("%s was rescheduled to %s so it doesn't overlap your other interview",
+					entry.Position.Name, p.start.Format("15:04"))
+				notices[entry.CandidateID] = append(notices[entry.CandidateID], message)
+
+				start := p.start
+				if err := tx.Omit(clause.Associations).Create(&models.QueueOptimization{
+					EventID:     entry.EventID,
+					CandidateID: entry.CandidateID,
+					PositionID:  entry.PositionID,
+					Type:        OptimizationConflict,
+					Details:     message,
+					Result:      OptimizationApplied,
+					BeforeETA:   entry.EstimatedTime,
+					AfterETA:    &start,
+					TimeSaved:   etaMinutesSaved(entry.EstimatedTime, &start),
+					ActorRole:   ActorSystem,
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Model(entry).UpdateColumns(map[string]interface{}{
+				"estimated_time":   p.start,
+				"conflict_delayed": p.delayed,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for candidateID, messages := range notices {
+		e.wsHub.BroadcastToUser(candidateID, Message{
+			Type: ConflictResolved,
+			Data: map[string]interface{}{
+				"conflicts":    messages,
+				"resolved":     true,
+				"candidate_id": candidateID,
+			},
+			Timestamp: now,
+		})
+	}
+
+	return nil
+}
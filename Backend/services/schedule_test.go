@@ -0,0 +1,38 @@
+package services
+
+import (
+	"interview-system/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScheduleEngineCoalescesRequests(t *testing.T) {
+	db := newTestDB(t)
+	event := models.Event{Name: "Fair", Date: time.Now(), Status: models.EventActive, AverageInterviewTime: 15, BufferTime: 5}
+	if err := db.Create(&event).Error; err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	engine := NewScheduleEngine(db, NewWebSocketHub(), newFakeClock(time.Now()))
+
+	var ran []int
+	engine.Request(event.ID, func() { ran = append(ran, 1) })
+	engine.Request(event.ID, nil)
+	engine.Request(event.ID, func() { ran = append(ran, 2) })
+
+	if len(engine.wake) != 1 {
+		t.Errorf("%d wake ups pending, want 1", len(engine.wake))
+	}
+	if len(engine.pending) != 1 {
+		t.Errorf("%d events pending, want 1", len(engine.pending))
+	}
+
+	engine.flush()
+
+	if !reflect.DeepEqual(ran, []int{1, 2}) {
+		t.Errorf("follow-ups ran as %v, want [1 2]", ran)
+	}
+	if len(engine.pending) != 0 {
+		t.Errorf("%d events still pending after flush", len(engine.pending))
+	}
+}
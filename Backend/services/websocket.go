@@ -14,9 +14,16 @@ const (
 	QueueUpdate      MessageType = "queue_update"
 	InterviewStatus  MessageType = "interview_status"
 	GroupInvitation  MessageType = "group_invitation"
+	GroupSelection   MessageType = "group_selection"
 	SystemNotification MessageType = "system_notification"
 	TimeWarning      MessageType = "time_warning"
 	ConflictResolved MessageType = "conflict_resolved"
+	QueuePromoted    MessageType = "queue_promoted"
+	JumpAheadSuggested MessageType = "jump_ahead_suggested"
+	CandidateCalled  MessageType = "candidate_called"
+	CandidateCheckedIn MessageType = "candidate_checked_in"
+	CandidateNoShow  MessageType = "candidate_no_show"
+	ApplicationStatus MessageType = "application_status"
 )
 
 type Message struct {
@@ -26,12 +33,14 @@ type Message struct {
 }
 
 type Client struct {
-	ID       string
-	UserID   uint
-	Role     string
-	Conn     *websocket.Conn
-	Send     chan []byte
-	Hub      *WebSocketHub
+	ID     string
+	UserID uint
+	Role   string
+	// SessionID is the login session whose token opened the connection
+	SessionID string
+	Conn      *websocket.Conn
+	Send      chan []byte
+	Hub       *WebSocketHub
 }
 
 type WebSocketHub struct {
@@ -39,6 +48,7 @@ type WebSocketHub struct {
 	broadcast  chan []byte
 	register   chan *Client
 	unregister chan *Client
+	disconnect chan func(*Client) bool
 }
 
 func NewWebSocketHub() *WebSocketHub {
@@ -47,6 +57,7 @@ func NewWebSocketHub() *WebSocketHub {
 		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		disconnect: make(chan func(*Client) bool),
 	}
 }
 
@@ -68,6 +79,15 @@ func (h *WebSocketHub) Run() {
 				log.Printf("Client %s disconnected", client.ID)
 			}
 
+		case match := <-h.disconnect:
+			for id, client := range h.clients {
+				if match(client) {
+					delete(h.clients, id)
+					close(client.Send)
+					log.Printf("Client %s disconnected: session revoked", client.ID)
+				}
+			}
+
 		case message := <-h.broadcast:
 			for _, client := range h.clients {
 				select {
@@ -177,4 +197,15 @@ func (h *WebSocketHub) BroadcastToAll(message Message) {
 	}
 
 	h.broadcast <- data
-}
\ No newline at end of file
+}
+
+// DisconnectUser closes every live connection of the user, e.g. after their
+// tokens were revoked.
+func (h *WebSocketHub) DisconnectUser(userID uint) {
+	h.disconnect <- func(client *Client) bool { return client.UserID == userID }
+}
+
+// DisconnectSession closes the connections opened with one session's tokens.
+func (h *WebSocketHub) DisconnectSession(sessionID string) {
+	h.disconnect <- func(client *Client) bool { return client.SessionID == sessionID }
+}
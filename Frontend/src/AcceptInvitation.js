@@ -0,0 +1,87 @@
+import React, { useEffect, useState } from 'react';
+import { useNavigate, useParams } from 'react-router-dom';
+import axios from 'axios';
+import './Login.css';
+
+// Landing page of an invitation link: the invited interviewer or admin
+// chooses their password here, then signs in normally.
+function AcceptInvitation() {
+  const { token } = useParams();
+  const navigate = useNavigate();
+  const [invitation, setInvitation] = useState(null);
+  const [password, setPassword] = useState('');
+  const [confirmPassword, setConfirmPassword] = useState('');
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  useEffect(() => {
+    axios.get(`http://www.bon.cc:8080/api/invitations/${token}`)
+      .then((response) => setInvitation(response.data))
+      .catch((err) => setError(err.response?.data?.error || 'Invitation not found'));
+  }, [token]);
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+
+    if (password !== confirmPassword) {
+      setError('Passwords do not match');
+      return;
+    }
+
+    setError('');
+    setLoading(true);
+    try {
+      await axios.post(`http://www.bon.cc:8080/api/invitations/${token}/accept`, { password });
+      navigate('/login');
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to set password');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="login-container">
+      <div className="login-box">
+        <h2>Set Your Password</h2>
+        {invitation && (
+          <p>
+            Welcome {invitation.name}! Your account is <code>{invitation.account}</code>
+            {invitation.company && <> at {invitation.company.name}</>}.
+          </p>
+        )}
+        {invitation && (
+          <form onSubmit={handleSubmit}>
+            <div className="form-group">
+              <label>Password</label>
+              <input
+                type="password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+                minLength={6}
+                required
+              />
+            </div>
+            <div className="form-group">
+              <label>Confirm Password</label>
+              <input
+                type="password"
+                value={confirmPassword}
+                onChange={(e) => setConfirmPassword(e.target.value)}
+                minLength={6}
+                required
+              />
+            </div>
+            {error && <div className="error-message">{error}</div>}
+            <button type="submit" disabled={loading}>
+              {loading ? 'Saving...' : 'Set Password'}
+            </button>
+          </form>
+        )}
+        {!invitation && error && <div className="error-message">{error}</div>}
+      </div>
+    </div>
+  );
+}
+
+export default AcceptInvitation;
@@ -1,6 +1,7 @@
 import React from 'react';
 import { BrowserRouter as Router, Routes, Route, Navigate } from 'react-router-dom';
 import Login from './Login';
+import AcceptInvitation from './AcceptInvitation';
 import Dashboard from './Dashboard';
 import InterviewSettings from './InterviewSettings';
 import CandidateDashboard from './CandidateDashboard';
@@ -14,6 +15,7 @@ function App() {
       <Routes>
         <Route path="/" element={<Navigate to="/login" replace />} />
         <Route path="/login" element={<Login />} />
+        <Route path="/invite/:token" element={<AcceptInvitation />} />
         <Route path="/dashboard" element={<Dashboard />} />
         <Route path="/settings" element={<InterviewSettings />} />
         <Route path="/candidate-dashboard" element={<CandidateDashboard />} />
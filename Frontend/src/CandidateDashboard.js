@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import axios from 'axios';
-import { getAuthToken, getCurrentUser, hasAuthChanged } from './authUtils';
+import { getAuthToken, getCurrentUser, hasAuthChanged, logout } from './authUtils';
 import './CandidateDashboard.css';
 
 function CandidateDashboard() {
@@ -333,18 +333,13 @@ function CandidateDashboard() {
   };
 
   const handleLogout = () => {
-    // Clear all stored data
-    localStorage.removeItem('token');
-    localStorage.removeItem('user');
-    sessionStorage.clear();
-
     // Clear component state
     setUser(null);
     setPositions([]);
     setMyQueues([]);
 
-    // Redirect to login
-    window.location.href = '/login';
+    // Revoke the session, clear stored data and redirect to login
+    logout();
   };
 
   const getQueueStatusColor = (status) => {
@@ -1,5 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import axios from 'axios';
+import { logout } from './authUtils';
 import './CompanyDashboard.css';
 
 function CompanyDashboard() {
@@ -16,6 +17,7 @@ function CompanyDashboard() {
   });
   const [loading, setLoading] = useState(false);
   const [notification, setNotification] = useState('');
+  const [invitationLink, setInvitationLink] = useState('');
   const [showModal, setShowModal] = useState(false);
   const [modalType, setModalType] = useState('');
   const [editItem, setEditItem] = useState(null);
@@ -174,22 +176,21 @@ function CompanyDashboard() {
   const handleCreateInterviewer = async (formData) => {
     setLoading(true);
     try {
-      const companyId = user?.company_id || user?.company?.id || 1;
-
+      const token = localStorage.getItem('token');
       const payload = {
         account: formData.account,
-        password: formData.password,
         name: formData.name,
         email: formData.email || '',
         phone: formData.phone || '',
-        employee_id: formData.employee_id || '',
-        role: 'interviewer',
-        company_id: companyId
+        employee_id: formData.employee_id || ''
       };
 
-      // Use register endpoint to create interviewer account
-      await axios.post('http://www.bon.cc:8080/api/register', payload);
+      // The interviewer sets their own password through the invitation link
+      const response = await axios.post('http://www.bon.cc:8080/api/company/interviewers', payload, {
+        headers: { Authorization: `Bearer ${token}` }
+      });
 
+      setInvitationLink(`${window.location.origin}/invite/${response.data.invitation_token}`);
       setNotification('Interviewer added successfully!');
       setShowModal(false);
       fetchInterviewers();
@@ -224,9 +225,7 @@ function CompanyDashboard() {
   };
 
   const handleLogout = () => {
-    localStorage.removeItem('token');
-    localStorage.removeItem('user');
-    window.location.href = '/login';
+    logout();
   };
 
   const openModal = (type, item = null) => {
@@ -261,6 +260,14 @@ function CompanyDashboard() {
         <div className="notification">{notification}</div>
       )}
 
+      {invitationLink && (
+        <div className="notification invitation-link">
+          Send this link to the interviewer so they can set their password:{' '}
+          <code>{invitationLink}</code>
+          <button className="cancel-btn" onClick={() => setInvitationLink('')}>Dismiss</button>
+        </div>
+      )}
+
       <div className="dashboard-tabs">
         <button
           className={`tab-btn ${activeTab === 'overview' ? 'active' : ''}`}
@@ -828,7 +835,6 @@ function InterviewerForm({ interviewer, onSubmit, onCancel, loading }) {
     email: interviewer?.email || '',
     phone: interviewer?.phone || '',
     account: interviewer?.account || '',
-    password: '',
     employee_id: interviewer?.employee_id || ''
   });
 
@@ -859,18 +865,6 @@ function InterviewerForm({ interviewer, onSubmit, onCancel, loading }) {
         />
       </div>
 
-      {!interviewer && (
-        <div className="form-group">
-          <label>Password*</label>
-          <input
-            type="password"
-            value={formData.password}
-            onChange={(e) => setFormData({...formData, password: e.target.value})}
-            required={!interviewer}
-          />
-        </div>
-      )}
-
       <div className="form-row">
         <div className="form-group">
           <label>Email</label>
@@ -1,5 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import axios from 'axios';
+import { logout } from './authUtils';
 import './InterviewerDashboard.css';
 
 function InterviewerDashboard() {
@@ -287,9 +288,7 @@ function InterviewerDashboard() {
   };
 
   const handleLogout = () => {
-    localStorage.removeItem('token');
-    localStorage.removeItem('user');
-    window.location.href = '/login';
+    logout();
   };
 
   const formatDuration = (seconds) => {
@@ -79,7 +79,7 @@ function Login() {
 
       if (response.data.token) {
         // Use the new auth utility for better tab isolation
-        storeAuth(response.data.token, response.data.user);
+        storeAuth(response.data.token, response.data.user, response.data.refresh_token);
 
         // Navigate based on user role
         const userRole = response.data.user.role;
@@ -1,4 +1,7 @@
 // Authentication utility functions to handle multi-tab scenarios
+import axios from 'axios';
+
+const API_BASE = 'http://www.bon.cc:8080/api';
 
 // Generate a unique tab ID
 const getTabId = () => {
@@ -11,12 +14,16 @@ const getTabId = () => {
 };
 
 // Store authentication data with tab information
-export const storeAuth = (token, user) => {
+export const storeAuth = (token, user, refreshToken) => {
   const tabId = getTabId();
 
   // Store in localStorage with tab information
   localStorage.setItem('token', token);
   localStorage.setItem('user', JSON.stringify(user));
+  if (refreshToken) {
+    localStorage.setItem('refreshToken', refreshToken);
+    sessionStorage.setItem('refreshToken', refreshToken);
+  }
   localStorage.setItem('currentTabId', tabId);
   localStorage.setItem(`lastLogin_${user.account}`, Date.now().toString());
 
@@ -69,11 +76,87 @@ export const getCurrentUser = () => {
 export const clearAuth = () => {
   sessionStorage.removeItem('token');
   sessionStorage.removeItem('user');
+  sessionStorage.removeItem('refreshToken');
   localStorage.removeItem('token');
   localStorage.removeItem('user');
+  localStorage.removeItem('refreshToken');
   localStorage.removeItem('currentTabId');
 };
 
+const getRefreshToken = () =>
+  sessionStorage.getItem('refreshToken') || localStorage.getItem('refreshToken');
+
+// Store a rotated token pair without touching the stored user
+const updateTokens = (token, refreshToken) => {
+  localStorage.setItem('token', token);
+  localStorage.setItem('refreshToken', refreshToken);
+  sessionStorage.setItem('token', token);
+  sessionStorage.setItem('refreshToken', refreshToken);
+};
+
+// Revoke the session on the server, then forget it locally
+export const logout = async () => {
+  const token = getAuthToken() || localStorage.getItem('token');
+  try {
+    if (token) {
+      await axios.post(`${API_BASE}/logout`, {}, {
+        headers: { Authorization: `Bearer ${token}` },
+        skipAuthRefresh: true
+      });
+    }
+  } catch (error) {
+    console.error('Logout failed:', error);
+  } finally {
+    clearAuth();
+    window.location.href = '/login';
+  }
+};
+
+// Concurrent 401s share a single refresh request, since each refresh token
+// can only be used once
+let refreshing = null;
+
+const refreshTokens = () => {
+  if (!refreshing) {
+    refreshing = axios
+      .post(`${API_BASE}/refresh`, { refresh_token: getRefreshToken() }, { skipAuthRefresh: true })
+      .then((response) => {
+        updateTokens(response.data.token, response.data.refresh_token);
+        return response.data.token;
+      })
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+};
+
+// Refresh the access token when a request is rejected with 401 and retry it
+// once; if the refresh fails the session is over and the user must log in again
+export const installAuthInterceptor = () => {
+  axios.interceptors.response.use(
+    (response) => response,
+    async (error) => {
+      const config = error.config;
+      if (!error.response || error.response.status !== 401 || !config ||
+          config.skipAuthRefresh || config._retried || !getRefreshToken()) {
+        return Promise.reject(error);
+      }
+
+      config._retried = true;
+      try {
+        const token = await refreshTokens();
+        config.headers = { ...config.headers, Authorization: `Bearer ${token}` };
+        return axios(config);
+      } catch (refreshError) {
+        clearAuth();
+        window.location.href = '/login';
+        return Promise.reject(refreshError);
+      }
+    }
+  );
+};
+
 // Check if authentication has changed (for detecting cross-tab login)
 export const hasAuthChanged = (originalUserId) => {
   const currentUser = getCurrentUser();